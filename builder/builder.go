@@ -2,30 +2,271 @@
 package builder
 
 import (
+	"archive/zip"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/module"
 	"github.com/ikedam/terraform-registry-builder/internal/provider"
 )
 
 // Builder is responsible for building a Terraform registry structure.
 type Builder struct {
-	srcDir string
-	dstDir string
+	srcDir    string
+	dstDir    string
+	protocols map[string][]string
+	force     bool
+
+	// DST is the site root rather than the namespace directory when any of
+	// namespace, namespaceFromDir and namespaceConfig is set.
+	siteRoot         bool
+	namespace        string
+	namespaceFromDir bool
+	namespaceConfig  *NamespaceConfig
+	providersPath    string
+	modulesPath      string
+
+	// goreleaser makes SRC a goreleaser dist directory.
+	goreleaser bool
+
+	// versionSHASums enables a single SHA256SUMS file per version covering all platforms.
+	versionSHASums bool
+
+	// mirrorHostname enables the network mirror tree under DST/<hostname>.
+	mirrorHostname string
+	// mirrorOnly publishes providers only to the network mirror tree.
+	mirrorOnly bool
+
+	// filesystemMirror enables writing providers to a filesystem mirror directory.
+	filesystemMirror *FilesystemMirror
+
+	// downloadURL builds absolute URLs for download indexes instead of relative ones.
+	downloadURL *DownloadURLTemplate
+
+	// workers is the number of provider files and modules processed concurrently.
+	workers int
+
+	// pruneRules enables pruning provider versions after a build.
+	pruneRules *PruneRules
+
+	// reindex rebuilds corrupted or lost versions indexes from the download directories.
+	reindex bool
+
+	// signer signs the SHA256SUMS files.
+	signer file.Signer
+
+	// dryRun makes a plan of the build instead of writing to DST.
+	dryRun bool
+
+	// lockTimeout is how long to wait for the lock of DST held by another process.
+	lockTimeout time.Duration
+	// staleLockAge is the age after which a lock of DST not refreshed by its holder is taken over.
+	staleLockAge time.Duration
+
+	// out receives the progress messages.
+	out io.Writer
+	// now returns the current time, recorded as the publish time of new versions.
+	now func() time.Time
+	// state is shared by the workers of a build.
+	state *buildState
+}
+
+// Default paths of the registries declared in the service discovery document.
+const (
+	DefaultProvidersPath = "/v1/providers/"
+	DefaultModulesPath   = "/v1/modules/"
+)
+
+// Defaults of the lock of DST.
+const (
+	DefaultLockTimeout  = 5 * time.Minute
+	DefaultStaleLockAge = 5 * time.Minute
+)
+
+// Option configures optional behavior of a Builder.
+type Option func(*Builder)
+
+// WithProtocols overrides the protocol versions of providers, keyed by provider type.
+// Overrides take precedence over protocol versions detected from registry manifests.
+func WithProtocols(protocols map[string][]string) Option {
+	return func(b *Builder) {
+		b.protocols = protocols
+	}
+}
+
+// WithForce enables republishing versions/platforms whose content differs from the published one.
+// Without it, such a difference is reported as an error.
+func WithForce(force bool) Option {
+	return func(b *Builder) {
+		b.force = force
+	}
+}
+
+// WithVersionSHASums enables the goreleaser compatible layout, maintaining a single signed
+// terraform-provider-TYPE_VERSION_SHA256SUMS file per version that lists the zip files of all platforms.
+func WithVersionSHASums(enabled bool) Option {
+	return func(b *Builder) {
+		b.versionSHASums = enabled
+	}
+}
+
+// WithDownloadURL writes absolute URLs built with the template into download indexes,
+// so that the files can be served from a host other than the registry.
+func WithDownloadURL(template *DownloadURLTemplate) Option {
+	return func(b *Builder) {
+		b.downloadURL = template
+	}
+}
+
+// WithNetworkMirror writes the provider network mirror tree for the hostname under DST/<hostname>,
+// next to the registry tree. Providers must have a namespace.
+func WithNetworkMirror(hostname string) Option {
+	return func(b *Builder) {
+		b.mirrorHostname = hostname
+	}
+}
+
+// WithNetworkMirrorOnly publishes providers only to the network mirror tree, instead of the registry tree.
+func WithNetworkMirrorOnly(enabled bool) Option {
+	return func(b *Builder) {
+		b.mirrorOnly = enabled
+	}
+}
+
+// WithFilesystemMirror also writes providers to a filesystem_mirror directory in the packed or unpacked layout.
+// Providers must have a namespace.
+func WithFilesystemMirror(mirror *FilesystemMirror) Option {
+	return func(b *Builder) {
+		b.filesystemMirror = mirror
+	}
+}
+
+// WithWorkers sets the number of provider files and modules processed concurrently.
+// Files of the same version/platform are still processed in order, and the result does not depend on the number.
+func WithWorkers(workers int) Option {
+	return func(b *Builder) {
+		b.workers = workers
+	}
+}
+
+// WithSigner sets the signer of the SHA256SUMS files.
+// Without it, files are signed with the GPG private key in the TFREGBUILDER_GPG_* environment variables.
+func WithSigner(signer file.Signer) Option {
+	return func(b *Builder) {
+		b.signer = signer
+	}
+}
+
+// WithDryRun makes Build only plan what it would publish, without writing anything.
+// Files are still discovered and parsed, the existing indexes are checked and the signing key is validated.
+// The plan is available with Plan after the build.
+func WithDryRun(enabled bool) Option {
+	return func(b *Builder) {
+		b.dryRun = enabled
+	}
+}
+
+// WithLockTimeout sets how long to wait for the lock of DST held by another process.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(b *Builder) {
+		b.lockTimeout = timeout
+	}
+}
+
+// WithStaleLockAge sets the age after which a lock of DST not refreshed by its holder is
+// considered stale and taken over. Holders refresh their locks while running.
+func WithStaleLockAge(age time.Duration) Option {
+	return func(b *Builder) {
+		b.staleLockAge = age
+	}
+}
+
+// WithGoreleaser treats SRC as a goreleaser dist directory, or a directory containing dist
+// directories, publishing the provider archives listed in their artifacts.json.
+func WithGoreleaser(enabled bool) Option {
+	return func(b *Builder) {
+		b.goreleaser = enabled
+	}
+}
+
+// WithSiteRoot makes DST the site root of the registry.
+// This is implied by the namespace options, and required to publish modules.
+func WithSiteRoot(enabled bool) Option {
+	return func(b *Builder) {
+		b.siteRoot = enabled
+	}
+}
+
+// WithNamespace makes DST the site root of the registry.
+// Providers are laid out under the providers path and the namespace, and the
+// service discovery document (.well-known/terraform.json) is written or merged.
+// The namespace is used for files whose namespace is not determined otherwise.
+func WithNamespace(namespace string) Option {
+	return func(b *Builder) {
+		b.namespace = namespace
+	}
+}
+
+// WithNamespaceFromDir makes DST the site root of the registry and uses the
+// first level sub-directory of SRC (SRC/<namespace>/...) as the namespace of each file.
+func WithNamespaceFromDir(enabled bool) Option {
+	return func(b *Builder) {
+		b.namespaceFromDir = enabled
+	}
+}
+
+// WithNamespaceConfig makes DST the site root of the registry and maps files to namespaces with the config.
+func WithNamespaceConfig(config *NamespaceConfig) Option {
+	return func(b *Builder) {
+		b.namespaceConfig = config
+	}
+}
+
+// WithProvidersPath sets the path of the provider registry used when DST is the site root.
+func WithProvidersPath(providersPath string) Option {
+	return func(b *Builder) {
+		b.providersPath = providersPath
+	}
+}
+
+// WithModulesPath sets the path of the module registry used when DST is the site root.
+func WithModulesPath(modulesPath string) Option {
+	return func(b *Builder) {
+		b.modulesPath = modulesPath
+	}
 }
 
 // New creates a new Builder instance.
-func New(srcDir, dstDir string) *Builder {
-	return &Builder{
-		srcDir: srcDir,
-		dstDir: dstDir,
+func New(srcDir, dstDir string, opts ...Option) *Builder {
+	b := &Builder{
+		srcDir:        srcDir,
+		dstDir:        dstDir,
+		providersPath: DefaultProvidersPath,
+		modulesPath:   DefaultModulesPath,
+		workers:       1,
+		lockTimeout:   DefaultLockTimeout,
+		staleLockAge:  DefaultStaleLockAge,
+		signer:        file.EnvSigner{},
+		out:           os.Stdout,
+		now:           time.Now,
+		state:         newBuildState(),
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
+	return b
 }
 
 // Build processes the source directory and builds the registry structure in the destination directory.
+// The lock of the destination directory is held during the build, and all changes are rolled back on any error.
 func (b *Builder) Build() error {
 	// Check if source directory exists
 	srcInfo, err := os.Stat(b.srcDir)
@@ -37,97 +278,387 @@ func (b *Builder) Build() error {
 		return fmt.Errorf("source path is not a directory")
 	}
 
+	if err := b.validateLayout(); err != nil {
+		return err
+	}
+	if err := b.validateMirrors(); err != nil {
+		return err
+	}
+
+	if b.dryRun {
+		return b.plan()
+	}
+
 	// Ensure destination directory exists
 	err = file.EnsureDir(b.dstDir)
 	if err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	// Find and process provider files
-	return b.processDirectory(b.srcDir)
+	return b.transaction(b.build)
+}
+
+// build finds provider files and modules in the source directory and publishes them.
+func (b *Builder) build() error {
+	// Find provider files and modules, then process them
+	jobs, err := b.jobs()
+	if err != nil {
+		return err
+	}
+	if err := b.runJobs(jobs); err != nil {
+		return err
+	}
+
+	if b.isPruneEnabled() {
+		if err := b.prune(); err != nil {
+			return err
+		}
+	}
+
+	// Declare the registries in the service discovery document
+	if b.isSiteRoot() && !b.mirrorOnly {
+		if err := b.writeDiscoveryDocument(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jobs starts a new build and returns the jobs processing provider files and modules in the source directory.
+func (b *Builder) jobs() ([]job, error) {
+	b.state = newBuildState()
+	if b.goreleaser {
+		return b.goreleaserDirJobs(b.srcDir)
+	}
+	return b.directoryJobs(b.srcDir)
+}
+
+// validateLayout validates the options describing the layout of DST.
+func (b *Builder) validateLayout() error {
+	if !b.isSiteRoot() {
+		return nil
+	}
+
+	var err error
+	if b.namespace != "" {
+		if err := provider.ValidateNamespace(b.namespace); err != nil {
+			return err
+		}
+	}
+	if b.providersPath, err = normalizeServicePath(b.providersPath); err != nil {
+		return fmt.Errorf("invalid providers path: %w", err)
+	}
+	if b.modulesPath, err = normalizeServicePath(b.modulesPath); err != nil {
+		return fmt.Errorf("invalid modules path: %w", err)
+	}
+	if b.providersPath == b.modulesPath {
+		return fmt.Errorf("providers path and modules path must differ: %s", b.providersPath)
+	}
+	return nil
 }
 
-// processDirectory walks through the directory and processes provider files.
-func (b *Builder) processDirectory(dir string) error {
+// isSiteRoot returns whether DST is the site root rather than the namespace directory.
+func (b *Builder) isSiteRoot() bool {
+	return b.siteRoot || b.namespace != "" || b.namespaceFromDir || b.namespaceConfig != nil
+}
+
+// registryDir returns the directory where provider namespaces, or provider types
+// when DST is the namespace directory, are laid out.
+func (b *Builder) registryDir() string {
+	if !b.isSiteRoot() {
+		return b.dstDir
+	}
+	return filepath.Join(b.dstDir, filepath.FromSlash(strings.Trim(b.providersPath, "/")))
+}
+
+// writeDiscoveryDocument writes or merges the service discovery document.
+// The module registry is declared only once modules are published.
+func (b *Builder) writeDiscoveryDocument() error {
+	services := map[string]string{
+		file.ProvidersServiceID: b.providersPath,
+	}
+	if b.isModulesPublished() {
+		services[file.ModulesServiceID] = b.modulesPath
+	}
+
+	discoveryPath := filepath.Join(b.dstDir, file.DiscoveryDocumentPath)
+	changed, err := file.UpdateDiscoveryDocument(discoveryPath, services)
+	if err != nil {
+		return fmt.Errorf("failed to write service discovery document: %w", err)
+	}
+	if changed {
+		fmt.Fprintf(b.out, "Updated service discovery document %s\n", discoveryPath)
+	}
+
+	return nil
+}
+
+// normalizeServicePath validates a path declared in the service discovery document
+// and makes sure it starts and ends with a slash.
+func normalizeServicePath(servicePath string) (string, error) {
+	trimmed := strings.Trim(servicePath, "/")
+	if trimmed == "" {
+		return "", fmt.Errorf("path must not be the site root: %q", servicePath)
+	}
+	for _, segment := range strings.Split(trimmed, "/") {
+		if segment == "" || segment == "." || segment == ".." || segment == ".well-known" {
+			return "", fmt.Errorf("invalid path: %q", servicePath)
+		}
+	}
+	return "/" + trimmed + "/", nil
+}
+
+// directoryJobs walks through the directory and returns the jobs processing provider files and modules.
+func (b *Builder) directoryJobs(dir string) ([]job, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return fmt.Errorf("failed to read directory %s: %w", dir, err)
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
 	}
 
+	var jobs []job
 	for _, entry := range entries {
 		path := filepath.Join(dir, entry.Name())
 
 		if entry.IsDir() {
+			if module.IsModuleName(entry.Name()) {
+				// Module source directories are packaged as a whole
+				jobs = append(jobs, b.moduleJob(path))
+				continue
+			}
+
 			// Recursively process subdirectories
-			if err := b.processDirectory(path); err != nil {
-				return err
+			subJobs, err := b.directoryJobs(path)
+			if err != nil {
+				return nil, err
 			}
+			jobs = append(jobs, subJobs...)
 		} else {
-			// Process files matching the provider pattern
-			if strings.HasPrefix(entry.Name(), "terraform-provider-") {
-				if err := b.processProviderFile(path); err != nil {
-					return err
-				}
+			// Process files matching the provider pattern.
+			// JSON files are manifests accompanying the providers.
+			if strings.HasPrefix(entry.Name(), "terraform-provider-") && !strings.HasSuffix(entry.Name(), ".json") {
+				jobs = append(jobs, b.providerFileJob(path))
+			} else if strings.HasSuffix(entry.Name(), module.ArchiveExt) && module.IsModuleName(entry.Name()) {
+				jobs = append(jobs, b.moduleJob(path))
 			}
 		}
 	}
 
-	return nil
+	return jobs, nil
 }
 
-// processProviderFile processes a single provider file.
-func (b *Builder) processProviderFile(filePath string) error {
+// providerSource describes a provider package to publish.
+type providerSource struct {
+	path  string                 // Path to the binary or zip file
+	info  *provider.ProviderInfo // Provider information
+	isZip bool                   // Whether the file is a zip file to publish as is
+	// protocols are protocol versions from a manifest accompanying the source, if any.
+	protocols []string
+	// shasum is the expected SHA256 hash of the zip file, if known.
+	shasum string
+	// namespacePath is the path to determine the namespace from, if other than path.
+	namespacePath string
+	// manifestPath is the registry manifest accompanying the source, if known.
+	manifestPath string
+	// checksumsPath and signaturePath are the SHA256SUMS file covering all platforms of the version
+	// released with the source and its signature, if any.
+	checksumsPath string
+	signaturePath string
+}
+
+// providerFileJob returns the job processing a single provider file.
+func (b *Builder) providerFileJob(filePath string) job {
 	// Parse provider information from file name
 	info, err := provider.ParseProviderFileName(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to parse provider file name %s: %w", filePath, err)
+		return errorJob(filePath, fmt.Errorf("failed to parse provider file name %s: %w", filePath, err))
+	}
+
+	return b.providerJob(&providerSource{
+		path:  filePath,
+		info:  info,
+		isZip: info.IsZipFile(filePath),
+	})
+}
+
+// providerJob returns the job publishing a provider package.
+// Jobs publishing the same version/platform share a lane.
+func (b *Builder) providerJob(src *providerSource) job {
+	// Determine the namespace to publish to
+	namespacePath := src.path
+	if src.namespacePath != "" {
+		namespacePath = src.namespacePath
+	}
+	namespace, err := b.resolveNamespace(namespacePath, src.info)
+	if err != nil {
+		return errorJob(src.path, err)
+	}
+	src.info.Namespace = namespace
+
+	return job{
+		lane: "provider:" + src.info.TargetDownloadPath(),
+		run: func(b *Builder) error {
+			return b.processProvider(src)
+		},
+	}
+}
+
+// errorJob returns a job failing with the error, reported in the order the source was found.
+func errorJob(srcPath string, err error) job {
+	return job{
+		lane: "error:" + srcPath,
+		run: func(b *Builder) error {
+			return err
+		},
+	}
+}
+
+// processProvider publishes a single provider package.
+// Zip files, hashes and per-platform signatures are created without holding the lock of the provider,
+// so that platforms of the same provider can be processed concurrently. The lock is held while
+// the files shared by the platforms, like the versions index, are read and written.
+func (b *Builder) processProvider(src *providerSource) error {
+	filePath := src.path
+	info := src.info
+
+	// Hash of the zip file to publish, calculated when needed
+	var hash string
+	var err error
+
+	// Verify the content against the known checksum
+	if src.shasum != "" {
+		if hash, err = sourceHash(src); err != nil {
+			return err
+		}
+		if hash != src.shasum {
+			return fmt.Errorf("checksum mismatch for %s: got %s, want %s", filePath, hash, src.shasum)
+		}
+	}
+
+	// Determine protocol versions for this provider
+	protocols, explicit, err := b.detectProtocols(src)
+	if err != nil {
+		return fmt.Errorf("failed to detect protocol versions for %s: %w", filePath, err)
 	}
 
+	if b.mirrorOnly {
+		return b.processMirrorProvider(src, hash)
+	}
+
+	versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+	unlock := b.lock(versionsIndexPath)
+	locked := true
+	defer func() {
+		if locked {
+			unlock()
+		}
+	}()
+
 	// First, check if this version/platform already exists in the index
-	versionsIndexPath := filepath.Join(b.dstDir, info.TargetVersionsIndexPath())
-	versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.Type)
+	versionsIndex, err := b.readVersionsIndex(info, versionsIndexPath)
 	if err != nil {
-		return fmt.Errorf("failed to read versions index file: %w", err)
+		return err
 	}
 
 	// Check if the version/platform already exists before adding it
 	needsAdding := true
-	for _, ver := range versionsIndex.Versions {
-		if ver.Version == info.Version {
-			for _, plat := range ver.Platforms {
-				if plat.OS == info.OS && plat.Arch == info.Arch {
-					needsAdding = false
-					break
-				}
+	if ver := versionsIndex.FindVersion(info.Version); ver != nil {
+		for _, plat := range ver.Platforms {
+			if plat.OS == info.OS && plat.Arch == info.Arch {
+				needsAdding = false
+				break
 			}
-			break
+		}
+
+		// Keep the protocols of the existing version unless they are explicitly specified
+		if !explicit {
+			protocols = ver.Protocols
 		}
 	}
 
+	targetZipPath := b.publishedZipPath(info)
 	if !needsAdding {
-		fmt.Printf("Skipped %s version %s for %s/%s (already in index)\n", info.Type, info.Version, info.OS, info.Arch)
-		return nil // Skip further processing for this file
+		// Compare the new artifact with the published one
+		if hash == "" {
+			if hash, err = sourceHash(src); err != nil {
+				return err
+			}
+		}
+		changed, err := b.isChanged(info, targetZipPath, hash)
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			if b.planProvider(PlanSkip, src) {
+				return nil
+			}
+			fmt.Fprintf(b.out, "Skipped %s version %s for %s/%s (already in index)\n", info.FullName(), info.Version, info.OS, info.Arch)
+			// The h1 hash and the mirrors can be added to existing registries
+			if _, err := os.Stat(h1Path(targetZipPath)); os.IsNotExist(err) {
+				if err := b.writeH1Hash(targetZipPath, h1Path(targetZipPath)); err != nil {
+					return err
+				}
+			}
+			if b.mirrorHostname != "" {
+				if err := b.updateMirror(info, targetZipPath, b.publishedSHASumsPath(info)); err != nil {
+					return err
+				}
+			}
+			// Protocols are still updated so that they can be fixed for existing versions
+			if err := b.updateProtocols(info, versionsIndex, versionsIndexPath, protocols); err != nil {
+				return err
+			}
+
+			unlock()
+			locked = false
+			if b.filesystemMirror != nil {
+				return b.updateFilesystemMirror(info, targetZipPath)
+			}
+			return nil
+		}
+
+		if !b.force {
+			if b.planProvider(PlanConflict, src) {
+				return nil
+			}
+			return fmt.Errorf("%s version %s for %s/%s is already published with different content: %s (use force mode to republish)", info.FullName(), info.Version, info.OS, info.Arch, filePath)
+		}
+		if b.planProvider(PlanRepublish, src) {
+			return nil
+		}
+
+		// The published files are shared with other platforms until replaced, so the lock is kept
+		fmt.Fprintf(b.out, "Republishing %s version %s for %s/%s (content changed)\n", info.FullName(), info.Version, info.OS, info.Arch)
+	} else {
+		if b.planProvider(PlanAdd, src) {
+			return nil
+		}
+		fmt.Fprintf(b.out, "Adding %s version %s for %s/%s to index\n", info.FullName(), info.Version, info.OS, info.Arch)
+
+		// Files of a new platform are not referred to by others until it is added to the index
+		unlock()
+		locked = false
 	}
 
-	fmt.Printf("Adding %s version %s for %s/%s to index\n", info.Type, info.Version, info.OS, info.Arch)
+	// The zip file is named for the layout, replacing the published one named otherwise
+	publishedZipPath := targetZipPath
+	targetZipPath = filepath.Join(b.registryDir(), info.TargetDownloadPath(), b.zipFileName(info))
 
 	// Create target directories
-	targetPath := filepath.Join(b.dstDir, info.TargetDownloadPath())
+	targetPath := filepath.Join(b.registryDir(), info.TargetDownloadPath())
 	if err = file.EnsureDir(targetPath); err != nil {
 		return fmt.Errorf("failed to create target directory %s: %w", targetPath, err)
 	}
 
 	// Create versions directory
-	versionsDir := filepath.Join(b.dstDir, info.Type, "versions")
+	versionsDir := filepath.Join(b.registryDir(), info.TargetVersionsPath())
 	if err = file.EnsureDir(versionsDir); err != nil {
 		return fmt.Errorf("failed to create versions directory %s: %w", versionsDir, err)
 	}
 
-	// Define target paths
-	targetZipPath := filepath.Join(b.dstDir, info.TargetZipPath())
-
 	// Process file based on its type
-	if info.IsZipFile(filePath) {
+	if src.isZip {
 		// Copy zip file directly
 		if err = file.CopyFile(filePath, targetZipPath); err != nil {
 			return fmt.Errorf("failed to copy zip file: %w", err)
@@ -139,30 +670,293 @@ func (b *Builder) processProviderFile(filePath string) error {
 		}
 	}
 
-	// Now add the version/platform to the index and write it
-	versionsIndex.AddVersion(info.Version, info.OS, info.Arch)
+	if hash == "" {
+		if hash, err = file.CalculateSHA256(targetZipPath); err != nil {
+			return fmt.Errorf("failed to calculate hash of %s: %w", targetZipPath, err)
+		}
+	}
+
+	// Record the h1 hash used by dependency lock files
+	if err = b.writeH1Hash(targetZipPath, h1Path(targetZipPath)); err != nil {
+		return err
+	}
+
+	var shaSumsPath, sigPath string
+	if !b.versionSHASums {
+		// Create SHA256SUMS file
+		shaSumsPath = filepath.Join(b.registryDir(), info.TargetSHASumsPath())
+		if err = file.WriteSHA256Sums(shaSumsPath, map[string]string{filepath.Base(targetZipPath): hash}); err != nil {
+			return fmt.Errorf("failed to create SHA sums file: %w", err)
+		}
+
+		// Sign SHA256SUMS file
+		sigPath = filepath.Join(b.registryDir(), info.TargetSigPath())
+		err = file.SignFile(b.signer, shaSumsPath, sigPath)
+		if err != nil {
+			return fmt.Errorf("failed to create signature file: %w", err)
+		}
+	}
+
+	if !locked {
+		unlock = b.lock(versionsIndexPath)
+		locked = true
+
+		// Other platforms may have been added meanwhile, whose protocols apply to this platform as well
+		versionsIndex, err = b.readVersionsIndex(info, versionsIndexPath)
+		if err != nil {
+			return err
+		}
+		if ver := versionsIndex.FindVersion(info.Version); ver != nil && !explicit {
+			protocols = ver.Protocols
+		}
+	}
+
+	// Add the version/platform to the index, which is written after all other files are in place
+	newVersion := versionsIndex.FindVersion(info.Version) == nil
+	versionsIndex.AddVersion(info.Version, info.OS, info.Arch, protocols)
+	if newVersion {
+		// Record the publish time, which republishing and adding platforms keep
+		publishedAt := b.now().UTC().Truncate(time.Second)
+		versionsIndex.FindVersion(info.Version).PublishedAt = &publishedAt
+	}
+
+	if b.versionSHASums {
+		// Regenerate and sign the SHA256SUMS file shared by all platforms
+		shaSumsPath, sigPath, err = b.writeVersionSHASums(src, versionsIndex, targetZipPath, hash, protocols)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Create index.json (download)
+	downloadIndexPath := filepath.Join(b.registryDir(), info.TargetDownloadIndexPath())
+	urls, err := b.downloadURLs(info, targetZipPath, shaSumsPath, sigPath)
+	if err != nil {
+		return err
+	}
+	if err = file.WriteDownloadIndex(b.signer, targetZipPath, hash, downloadIndexPath, urls, protocols); err != nil {
+		return fmt.Errorf("failed to create download index file: %w", err)
+	}
+	if publishedZipPath != targetZipPath {
+		for _, path := range []string{publishedZipPath, h1Path(publishedZipPath)} {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+	}
+
+	if b.versionSHASums {
+		// Point the other platforms to the shared SHA256SUMS file
+		if err := b.linkVersionSHASums(info, versionsIndex, shaSumsPath, sigPath); err != nil {
+			return err
+		}
+	}
+
+	if b.mirrorHostname != "" {
+		if err := b.updateMirror(info, targetZipPath, shaSumsPath); err != nil {
+			return err
+		}
+	}
+
+	// Publish the version/platform now that its artifacts are durable
 	if err = file.WriteVersionsIndex(versionsIndexPath, versionsIndex); err != nil {
 		return fmt.Errorf("failed to write versions index file: %w", err)
 	}
 
-	// Create SHA256SUMS file
-	shaSumsPath := filepath.Join(b.dstDir, info.TargetSHASumsPath())
-	_, err = file.WriteSHA256SumsFile(targetZipPath, shaSumsPath)
+	// Make sure other platforms of the same version advertise the same protocols
+	if err := b.updateProtocols(info, versionsIndex, versionsIndexPath, protocols); err != nil {
+		return err
+	}
+
+	unlock()
+	locked = false
+	if b.filesystemMirror != nil {
+		return b.updateFilesystemMirror(info, targetZipPath)
+	}
+	return nil
+}
+
+// isChanged returns whether the hash of the zip file to publish differs from the one of the published
+// zip file at zipPath recorded in the SHA256SUMS file.
+func (b *Builder) isChanged(info *provider.ProviderInfo, zipPath, hash string) (bool, error) {
+	shaSumsPath := b.publishedSHASumsPath(info)
+	sums, err := file.ReadSHA256SumsFile(shaSumsPath)
 	if err != nil {
-		return fmt.Errorf("failed to create SHA sums file: %w", err)
+		return false, fmt.Errorf("failed to read published checksums of %s version %s for %s/%s: %w", info.FullName(), info.Version, info.OS, info.Arch, err)
+	}
+
+	publishedHash, ok := sums[filepath.Base(zipPath)]
+	if !ok {
+		return false, fmt.Errorf("published checksums %s do not contain %s", shaSumsPath, filepath.Base(zipPath))
 	}
 
-	// Sign SHA256SUMS file
-	sigPath := filepath.Join(b.dstDir, info.TargetSigPath())
-	_, err = file.SignFile(shaSumsPath, sigPath)
+	return publishedHash != hash, nil
+}
+
+// writeH1Hash records the "h1:" hash of the zip file in the sidecar file.
+func (b *Builder) writeH1Hash(zipPath, h1Path string) error {
+	_, err := file.WriteH1HashFile(zipPath, h1Path)
+	if errors.Is(err, zip.ErrFormat) {
+		// Not a zip we can look into; the package is published without the h1 hash
+		fmt.Fprintf(b.out, "Warning: could not calculate the h1 hash of %s: %v\n", zipPath, err)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to create h1 hash file: %w", err)
+	}
+	return nil
+}
+
+// sourceHash returns the SHA256 hash of the zip file that would be published for the provider source.
+func sourceHash(src *providerSource) (string, error) {
+	var hash string
+	var err error
+	if src.isZip {
+		hash, err = file.CalculateSHA256(src.path)
+	} else {
+		hash, err = file.CalculateZipSHA256FromBinary(src.path)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create signature file: %w", err)
+		return "", fmt.Errorf("failed to calculate hash of %s: %w", src.path, err)
 	}
+	return hash, nil
+}
 
-	// Create index.json (download)
-	downloadIndexPath := filepath.Join(b.dstDir, info.TargetDownloadIndexPath())
-	if err = file.WriteDownloadIndex(targetZipPath, shaSumsPath, sigPath, downloadIndexPath); err != nil {
-		return fmt.Errorf("failed to create download index file: %w", err)
+// publishedSHASumsPath returns the path of the SHA256SUMS file listing the published zip file.
+// The download index tells which of the per-platform and the per-version files is in use.
+func (b *Builder) publishedSHASumsPath(info *provider.ProviderInfo) string {
+	versionSHASumsPath := filepath.Join(b.registryDir(), info.TargetVersionSHASumsPath())
+	downloadIndex, err := file.ReadDownloadIndex(filepath.Join(b.registryDir(), info.TargetDownloadIndexPath()))
+	if err == nil {
+		if shaSumsURL, err := url.Parse(downloadIndex.ShasumsURL); err == nil && path.Base(shaSumsURL.Path) == info.TargetVersionSHASumsFileName() {
+			return versionSHASumsPath
+		}
+	} else if b.versionSHASums {
+		if _, err := os.Stat(versionSHASumsPath); err == nil {
+			return versionSHASumsPath
+		}
+	}
+	return filepath.Join(b.registryDir(), info.TargetSHASumsPath())
+}
+
+// zipFileName returns the name of the zip file to publish for the platform.
+// In the per-version SHA256SUMS layout, it is named the way upstream releases are,
+// so that the SHA256SUMS file matches the one of the releases.
+func (b *Builder) zipFileName(info *provider.ProviderInfo) string {
+	if b.versionSHASums {
+		return info.TargetUpstreamZipFileName()
+	}
+	return info.TargetZipFileName()
+}
+
+// publishedZipPath returns the path of the published zip file of the platform,
+// named as recorded in its download index.
+func (b *Builder) publishedZipPath(info *provider.ProviderInfo) string {
+	name := b.zipFileName(info)
+	downloadIndex, err := file.ReadDownloadIndex(filepath.Join(b.registryDir(), info.TargetDownloadIndexPath()))
+	if err == nil && downloadIndex.Filename != "" && downloadIndex.Filename == filepath.Base(downloadIndex.Filename) && downloadIndex.Filename != ".." {
+		name = downloadIndex.Filename
+	}
+	return filepath.Join(b.registryDir(), info.TargetDownloadPath(), name)
+}
+
+// h1Path returns the path of the file recording the "h1:" hash of the zip file.
+func h1Path(zipPath string) string {
+	return zipPath + ".h1"
+}
+
+// detectProtocols determines the protocol versions of a provider package.
+// It returns whether the protocols were explicitly specified, either by an override
+// or by a registry manifest, rather than falling back to the defaults.
+func (b *Builder) detectProtocols(src *providerSource) ([]string, bool, error) {
+	filePath := src.path
+	info := src.info
+
+	// Per-provider overrides take precedence
+	if protocols, ok := b.protocols[info.Type]; ok {
+		if err := file.ValidateProtocols(protocols); err != nil {
+			return nil, false, fmt.Errorf("invalid protocol override for %s: %w", info.Type, err)
+		}
+		return protocols, true, nil
+	}
+
+	// Manifest accompanying the source
+	if src.protocols != nil {
+		return src.protocols, true, nil
+	}
+
+	// Manifests placed next to the provider file, as goreleaser does
+	if manifestPath := sourceManifestPath(src); manifestPath != "" {
+		manifest, err := file.ReadRegistryManifest(manifestPath)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", manifestPath, err)
+		}
+		return manifest.Metadata.ProtocolVersions, true, nil
+	}
+
+	// Manifest packaged inside the zip file
+	if src.isZip {
+		manifest, err := file.ReadRegistryManifestFromZip(filePath)
+		if errors.Is(err, zip.ErrFormat) {
+			// Not a zip we can look into; the package is published as is
+			fmt.Fprintf(b.out, "Warning: could not look for a registry manifest in %s: %v\n", filePath, err)
+		} else if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", filePath, err)
+		} else if manifest != nil {
+			return manifest.Metadata.ProtocolVersions, true, nil
+		}
+	}
+
+	return file.DefaultProtocols, false, nil
+}
+
+// sourceManifestPath returns the path of the registry manifest accompanying the provider source,
+// or an empty string if there is none.
+func sourceManifestPath(src *providerSource) string {
+	if src.manifestPath != "" {
+		return src.manifestPath
+	}
+	dir := filepath.Dir(src.path)
+	for _, name := range src.info.ManifestFileNames() {
+		manifestPath := filepath.Join(dir, name)
+		if _, err := os.Stat(manifestPath); err == nil {
+			return manifestPath
+		}
+	}
+	return ""
+}
+
+// updateProtocols updates the protocols of a version in the versions index and
+// in the download indexes of all its platforms if they differ.
+func (b *Builder) updateProtocols(info *provider.ProviderInfo, versionsIndex *file.VersionsIndex, versionsIndexPath string, protocols []string) error {
+	ver := versionsIndex.FindVersion(info.Version)
+	if ver == nil {
+		return nil
+	}
+
+	changed := versionsIndex.SetProtocols(info.Version, protocols)
+	if changed {
+		fmt.Fprintf(b.out, "Updating protocols of %s version %s to %s\n", info.FullName(), info.Version, strings.Join(protocols, ", "))
+		if err := file.WriteVersionsIndex(versionsIndexPath, versionsIndex); err != nil {
+			return fmt.Errorf("failed to write versions index file: %w", err)
+		}
+	}
+
+	for _, plat := range ver.Platforms {
+		platInfo := *info
+		platInfo.OS = plat.OS
+		platInfo.Arch = plat.Arch
+
+		downloadIndexPath := filepath.Join(b.registryDir(), platInfo.TargetDownloadIndexPath())
+		downloadIndex, err := file.ReadDownloadIndex(downloadIndexPath)
+		if err != nil {
+			return fmt.Errorf("failed to read download index file: %w", err)
+		}
+		if file.EqualProtocols(downloadIndex.Protocols, protocols) {
+			continue
+		}
+		if err := file.UpdateDownloadIndexProtocols(downloadIndexPath, protocols); err != nil {
+			return fmt.Errorf("failed to update download index file: %w", err)
+		}
 	}
 
 	return nil
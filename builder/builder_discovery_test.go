@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBuilderSiteRoot verifies that providers are laid out under the providers path
+// and that the service discovery document is written when DST is the site root.
+func TestBuilderSiteRoot(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_discovery_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_discovery_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	err = os.WriteFile(filepath.Join(srcDir, "terraform-provider-test_v1.0.0_linux_amd64"), []byte("mock binary content"), 0755)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Pre-existing document with services managed by others
+	discoveryPath := filepath.Join(dstDir, ".well-known", "terraform.json")
+	if err := os.MkdirAll(filepath.Dir(discoveryPath), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(discoveryPath, []byte(`{"modules.v1": "/v1/modules/"}`), 0644); err != nil {
+		t.Fatalf("Failed to create service discovery document: %v", err)
+	}
+
+	b := New(srcDir, dstDir, WithNamespace("example"), WithProvidersPath("registry/providers"))
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	expectedFiles := []string{
+		filepath.Join(dstDir, "registry", "providers", "example", "test", "versions", "index.json"),
+		filepath.Join(dstDir, "registry", "providers", "example", "test", "1.0.0", "download", "linux", "amd64", "index.json"),
+		filepath.Join(dstDir, "registry", "providers", "example", "test", "1.0.0", "download", "linux", "amd64", "terraform-provider-test_v1.0.0_linux_amd64.zip"),
+	}
+	for _, expectedFile := range expectedFiles {
+		if _, err := os.Stat(expectedFile); os.IsNotExist(err) {
+			t.Errorf("Expected file not created: %s", expectedFile)
+		}
+	}
+
+	data, err := os.ReadFile(discoveryPath)
+	if err != nil {
+		t.Fatalf("Failed to read service discovery document: %v", err)
+	}
+	var document map[string]string
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Failed to parse service discovery document: %v", err)
+	}
+	if document["providers.v1"] != "/registry/providers/" {
+		t.Errorf("providers.v1 = %q, want %q", document["providers.v1"], "/registry/providers/")
+	}
+	if document["modules.v1"] != "/v1/modules/" {
+		t.Errorf("modules.v1 = %q, want %q", document["modules.v1"], "/v1/modules/")
+	}
+
+	t.Run("invalid providers path", func(t *testing.T) {
+		b := New(srcDir, dstDir, WithNamespace("example"), WithProvidersPath("../providers"))
+		if err := b.Build(); err == nil {
+			t.Error("Build() error = nil, want error")
+		}
+	})
+}
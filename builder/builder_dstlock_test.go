@@ -0,0 +1,55 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderLocksDst verifies that a build fails naming the holder when DST is locked by another build,
+// and that the lock is released after a build.
+func TestBuilderLocksDst(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_dstlock_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_dstlock_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	if err := os.WriteFile(filepath.Join(srcDir, "terraform-provider-locked_v1.0.0_linux_amd64"), []byte("content"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	lock, err := file.LockDir(dstDir, 0, time.Minute)
+	if err != nil {
+		t.Fatalf("LockDir() error = %v", err)
+	}
+
+	err = New(srcDir, dstDir, WithLockTimeout(0)).Build()
+	if err == nil || !strings.Contains(err.Error(), "is locked by process") {
+		t.Errorf("Build() error = %v, want locked error", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "locked")); !os.IsNotExist(err) {
+		t.Errorf("Provider is published while DST is locked: %v", err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if err := New(srcDir, dstDir, WithLockTimeout(0)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, file.LockFileName)); !os.IsNotExist(err) {
+		t.Errorf("Lock file is not removed after Build(): %v", err)
+	}
+}
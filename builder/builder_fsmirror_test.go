@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderFilesystemMirror verifies that providers are written to a filesystem mirror
+// in the packed and unpacked layouts.
+func TestBuilderFilesystemMirror(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_fsmirror_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	binaryPath := filepath.Join(srcDir, "terraform-provider-fsm_v1.0.0_linux_amd64")
+	if err := os.WriteFile(binaryPath, []byte("mock binary content"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		unpacked bool
+		path     string
+	}{
+		{
+			name: "packed",
+			path: filepath.Join("registry.example.com", "example", "fsm", "terraform-provider-fsm_1.0.0_linux_amd64.zip"),
+		},
+		{
+			name:     "unpacked",
+			unpacked: true,
+			path:     filepath.Join("registry.example.com", "example", "fsm", "1.0.0", "linux_amd64", "terraform-provider-fsm_v1.0.0"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dstDir := t.TempDir()
+			mirrorDir := t.TempDir()
+			newBuilder := func() *Builder {
+				return New(srcDir, dstDir, WithNamespace("example"), WithFilesystemMirror(&FilesystemMirror{
+					Dir:      mirrorDir,
+					Hostname: "registry.example.com",
+					Unpacked: tt.unpacked,
+				}))
+			}
+
+			if err := newBuilder().Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(mirrorDir, tt.path)); err != nil {
+				t.Errorf("Expected file not created: %s", tt.path)
+			}
+
+			zipPath := filepath.Join(dstDir, "v1", "providers", "example", "fsm", "1.0.0", "download", "linux", "amd64", "terraform-provider-fsm_v1.0.0_linux_amd64.zip")
+			var mirrorHash, wantHash string
+			if tt.unpacked {
+				mirrorHash, err = file.CalculateDirH1Hash(filepath.Dir(filepath.Join(mirrorDir, tt.path)))
+				if err != nil {
+					t.Fatalf("CalculateDirH1Hash() error = %v", err)
+				}
+				wantHash, err = file.CalculateZipH1Hash(zipPath)
+			} else {
+				mirrorHash, err = file.CalculateSHA256(filepath.Join(mirrorDir, tt.path))
+				if err != nil {
+					t.Fatalf("CalculateSHA256() error = %v", err)
+				}
+				wantHash, err = file.CalculateSHA256(zipPath)
+			}
+			if err != nil {
+				t.Fatalf("Failed to calculate hash of published zip file: %v", err)
+			}
+			if mirrorHash != wantHash {
+				t.Errorf("Mirrored package hash = %v, want %v", mirrorHash, wantHash)
+			}
+
+			// Rebuilding leaves the mirror as is
+			if err := newBuilder().Build(); err != nil {
+				t.Errorf("Second Build() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("without namespace", func(t *testing.T) {
+		err := New(srcDir, t.TempDir(), WithFilesystemMirror(&FilesystemMirror{
+			Dir:      t.TempDir(),
+			Hostname: "registry.example.com",
+		})).Build()
+		if err == nil {
+			t.Error("Build() error = nil, want namespace error")
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package builder
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// writeGoreleaserDist creates a goreleaser dist directory with zip archives for the platforms.
+func writeGoreleaserDist(t *testing.T, distDir string, platforms [][2]string) {
+	t.Helper()
+
+	if err := os.MkdirAll(distDir, 0755); err != nil {
+		t.Fatalf("Failed to create dist directory: %v", err)
+	}
+
+	// The manifest is listed in the checksums file as well
+	manifest := `{"version": 1, "metadata": {"protocol_versions": ["5.0"]}}`
+	manifestPath := filepath.Join(distDir, "terraform-provider-rel_1.2.3_manifest.json")
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+	manifestHash, err := file.CalculateSHA256(manifestPath)
+	if err != nil {
+		t.Fatalf("Failed to calculate hash: %v", err)
+	}
+	sums := []string{fmt.Sprintf("%s  %s\n", manifestHash, filepath.Base(manifestPath))}
+
+	var artifacts []string
+	for _, platform := range platforms {
+		name := fmt.Sprintf("terraform-provider-rel_1.2.3_%s_%s.zip", platform[0], platform[1])
+		zipPath := filepath.Join(distDir, name)
+
+		zipFile, err := os.Create(zipPath)
+		if err != nil {
+			t.Fatalf("Failed to create zip file: %v", err)
+		}
+		zipWriter := zip.NewWriter(zipFile)
+		w, err := zipWriter.Create("terraform-provider-rel_v1.2.3")
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte("binary for " + name)); err != nil {
+			t.Fatalf("Failed to write zip entry: %v", err)
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatalf("Failed to close zip writer: %v", err)
+		}
+		zipFile.Close()
+
+		hash, err := file.CalculateSHA256(zipPath)
+		if err != nil {
+			t.Fatalf("Failed to calculate hash: %v", err)
+		}
+		sums = append(sums, fmt.Sprintf("%s  %s\n", hash, name))
+
+		artifacts = append(artifacts, fmt.Sprintf(`{"name": %q, "path": "dist/%s", "goos": %q, "goarch": %q, "type": "Archive", "extra": {"Format": "zip"}}`, name, name, platform[0], platform[1]))
+	}
+	artifacts = append(artifacts,
+		`{"name": "terraform-provider-rel_1.2.3_SHA256SUMS", "path": "dist/terraform-provider-rel_1.2.3_SHA256SUMS", "type": "Checksum"}`,
+		`{"name": "terraform-provider-rel_1.2.3_manifest.json", "path": "dist/terraform-provider-rel_1.2.3_manifest.json", "type": "Uploadable File"}`,
+	)
+
+	// goreleaser sorts the lines of the checksums file
+	sort.Strings(sums)
+
+	files := map[string]string{
+		"metadata.json":  `{"project_name": "terraform-provider-rel", "tag": "v1.2.3", "version": "1.2.3"}`,
+		"artifacts.json": "[" + strings.Join(artifacts, ",") + "]",
+		"terraform-provider-rel_1.2.3_SHA256SUMS": strings.Join(sums, ""),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(distDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+}
+
+// TestBuilderGoreleaser verifies that provider archives are published from a goreleaser dist directory.
+func TestBuilderGoreleaser(t *testing.T) {
+	projectDir, err := os.MkdirTemp("", "builder_goreleaser_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(projectDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_goreleaser_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	distDir := filepath.Join(projectDir, "dist")
+	platforms := [][2]string{{"linux", "amd64"}, {"darwin", "arm64"}}
+	writeGoreleaserDist(t, distDir, platforms)
+
+	b := New(distDir, dstDir, WithGoreleaser(true))
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	for _, platform := range platforms {
+		zipPath := filepath.Join(dstDir, "rel", "1.2.3", "download", platform[0], platform[1], "terraform-provider-rel_v1.2.3_"+platform[0]+"_"+platform[1]+".zip")
+		if _, err := os.Stat(zipPath); err != nil {
+			t.Errorf("Expected file not created: %s", zipPath)
+		}
+	}
+	assertProtocols(t, dstDir, "rel", "1.2.3", platforms, []string{"5.0"})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		// Corrupt an archive after the checksums were computed
+		zipPath := filepath.Join(distDir, "terraform-provider-rel_1.2.3_linux_amd64.zip")
+		if err := os.WriteFile(zipPath, []byte("corrupted"), 0644); err != nil {
+			t.Fatalf("Failed to corrupt zip file: %v", err)
+		}
+
+		otherDstDir := t.TempDir()
+		if err := New(distDir, otherDstDir, WithGoreleaser(true)).Build(); err == nil {
+			t.Error("Build() error = nil, want checksum error")
+		}
+	})
+}
+
+// TestBuilderGoreleaserNamespaceFromDir verifies that the namespace of goreleaser archives
+// is determined from the location of the dist directory under SRC.
+func TestBuilderGoreleaserNamespaceFromDir(t *testing.T) {
+	srcDir := t.TempDir()
+	platforms := [][2]string{{"linux", "amd64"}}
+	writeGoreleaserDist(t, filepath.Join(srcDir, "team-a", "dist"), platforms)
+	writeGoreleaserDist(t, filepath.Join(srcDir, "team-b", "dist"), platforms)
+	moveGoreleaserArchive(t, filepath.Join(srcDir, "team-b", "dist"), "terraform-provider-rel_1.2.3_linux_amd64.zip", "build")
+
+	t.Run("dist directories under SRC", func(t *testing.T) {
+		dstDir := t.TempDir()
+		if err := New(srcDir, dstDir, WithGoreleaser(true), WithNamespaceFromDir(true)).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		for _, namespace := range []string{"team-a", "team-b"} {
+			zipPath := filepath.Join(dstDir, "v1", "providers", namespace, "rel", "1.2.3", "download", "linux", "amd64", "terraform-provider-rel_v1.2.3_linux_amd64.zip")
+			if _, err := os.Stat(zipPath); err != nil {
+				t.Errorf("Expected file not created: %s", zipPath)
+			}
+		}
+	})
+
+	t.Run("archives outside SRC", func(t *testing.T) {
+		// The archive of team-b is in the project directory, outside of the dist directory
+		dstDir := t.TempDir()
+		distDir := filepath.Join(srcDir, "team-b", "dist")
+		if err := New(distDir, dstDir, WithGoreleaser(true), WithNamespaceFromDir(true), WithNamespace("fallback")).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		zipPath := filepath.Join(dstDir, "v1", "providers", "fallback", "rel", "1.2.3", "download", "linux", "amd64", "terraform-provider-rel_v1.2.3_linux_amd64.zip")
+		if _, err := os.Stat(zipPath); err != nil {
+			t.Errorf("Expected file not created: %s", zipPath)
+		}
+	})
+}
+
+// moveGoreleaserArchive moves an archive of the dist directory to a sibling directory of it,
+// updating the path in artifacts.json.
+func moveGoreleaserArchive(t *testing.T, distDir, name, dir string) {
+	t.Helper()
+
+	projectDir := filepath.Dir(distDir)
+	if err := os.MkdirAll(filepath.Join(projectDir, dir), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Rename(filepath.Join(distDir, name), filepath.Join(projectDir, dir, name)); err != nil {
+		t.Fatalf("Failed to move archive: %v", err)
+	}
+
+	artifactsPath := filepath.Join(distDir, "artifacts.json")
+	artifacts, err := os.ReadFile(artifactsPath)
+	if err != nil {
+		t.Fatalf("Failed to read artifacts.json: %v", err)
+	}
+	artifacts = []byte(strings.Replace(string(artifacts), `"dist/`+name+`"`, `"`+dir+`/`+name+`"`, 1))
+	if err := os.WriteFile(artifactsPath, artifacts, 0644); err != nil {
+		t.Fatalf("Failed to write artifacts.json: %v", err)
+	}
+}
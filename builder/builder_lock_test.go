@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderLockHashes verifies that h1 hashes are recorded for the published zip files
+// and collected with the zh hashes of all platforms for dependency lock files.
+func TestBuilderLockHashes(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_lock_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_lock_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	platforms := [][2]string{{"linux", "amd64"}, {"darwin", "arm64"}}
+	for _, platform := range platforms {
+		name := "terraform-provider-lock_v1.0.0_" + platform[0] + "_" + platform[1]
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("mock binary content "+name), 0755); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	if err := New(srcDir, dstDir).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var want []string
+	for _, platform := range platforms {
+		downloadDir := filepath.Join(dstDir, "lock", "1.0.0", "download", platform[0], platform[1])
+		zipPath := filepath.Join(downloadDir, "terraform-provider-lock_v1.0.0_"+platform[0]+"_"+platform[1]+".zip")
+
+		h1, err := file.ReadH1HashFile(zipPath + ".h1")
+		if err != nil {
+			t.Fatalf("ReadH1HashFile() error = %v", err)
+		}
+		calculated, err := file.CalculateZipH1Hash(zipPath)
+		if err != nil {
+			t.Fatalf("CalculateZipH1Hash() error = %v", err)
+		}
+		if h1 != calculated {
+			t.Errorf("Recorded h1 hash = %v, want %v", h1, calculated)
+		}
+
+		hash, err := file.CalculateSHA256(zipPath)
+		if err != nil {
+			t.Fatalf("Failed to calculate hash: %v", err)
+		}
+		want = append(want, h1, "zh:"+hash)
+	}
+	sort.Strings(want)
+
+	assertHashes := func(t *testing.T) {
+		got, err := New("", dstDir).LockHashes("", "lock", "1.0.0")
+		if err != nil {
+			t.Fatalf("LockHashes() error = %v", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("LockHashes() = %v, want %v", got, want)
+		}
+	}
+	assertHashes(t)
+
+	t.Run("without sidecar", func(t *testing.T) {
+		h1Path := filepath.Join(dstDir, "lock", "1.0.0", "download", "linux", "amd64", "terraform-provider-lock_v1.0.0_linux_amd64.zip.h1")
+		if err := os.Remove(h1Path); err != nil {
+			t.Fatalf("Failed to remove h1 hash file: %v", err)
+		}
+		assertHashes(t)
+
+		// Rebuilding records the missing h1 hash
+		if err := New(srcDir, dstDir).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if _, err := os.Stat(h1Path); err != nil {
+			t.Errorf("h1 hash file not recreated: %v", err)
+		}
+	})
+
+	t.Run("unpublished version", func(t *testing.T) {
+		if _, err := New("", dstDir).LockHashes("", "lock", "2.0.0"); err == nil {
+			t.Error("LockHashes() error = nil, want error")
+		}
+	})
+}
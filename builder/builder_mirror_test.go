@@ -0,0 +1,149 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderNetworkMirror verifies that the network mirror tree is written next to the registry tree
+// and refers to the published zip files.
+func TestBuilderNetworkMirror(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_mirror_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_mirror_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	for _, name := range []string{
+		"terraform-provider-mirror_v1.0.0_linux_amd64",
+		"terraform-provider-mirror_v1.0.0_darwin_arm64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("mock binary content "+name), 0755); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	if err := New(srcDir, dstDir, WithNamespace("example"), WithNetworkMirror("registry.example.com")).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	mirrorDir := filepath.Join(dstDir, "registry.example.com", "example", "mirror")
+	mirrorIndex, err := file.ReadMirrorIndex(filepath.Join(mirrorDir, "index.json"))
+	if err != nil {
+		t.Fatalf("ReadMirrorIndex() error = %v", err)
+	}
+	if _, ok := mirrorIndex.Versions["1.0.0"]; !ok || len(mirrorIndex.Versions) != 1 {
+		t.Errorf("Versions = %v, want [1.0.0]", mirrorIndex.Versions)
+	}
+
+	mirrorVersion, err := file.ReadMirrorVersion(filepath.Join(mirrorDir, "1.0.0.json"))
+	if err != nil {
+		t.Fatalf("ReadMirrorVersion() error = %v", err)
+	}
+	if len(mirrorVersion.Archives) != 2 {
+		t.Errorf("Archives = %v, want 2 platforms", mirrorVersion.Archives)
+	}
+
+	archive := mirrorVersion.Archives["linux_amd64"]
+	zipPath := filepath.Join(dstDir, "v1", "providers", "example", "mirror", "1.0.0", "download", "linux", "amd64", "terraform-provider-mirror_v1.0.0_linux_amd64.zip")
+	if resolved := filepath.Join(mirrorDir, filepath.FromSlash(archive.URL)); resolved != zipPath {
+		t.Errorf("URL = %v resolves to %v, want %v", archive.URL, resolved, zipPath)
+	}
+
+	hash, err := file.CalculateSHA256(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to calculate hash: %v", err)
+	}
+	h1, err := file.CalculateZipH1Hash(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to calculate h1 hash: %v", err)
+	}
+	if len(archive.Hashes) != 2 || archive.Hashes[0] != h1 || archive.Hashes[1] != "zh:"+hash {
+		t.Errorf("Hashes = %v, want [%s zh:%s]", archive.Hashes, h1, hash)
+	}
+}
+
+// TestBuilderNetworkMirrorOnly verifies that providers can be published only to the network mirror tree.
+func TestBuilderNetworkMirrorOnly(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_mirror_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_mirror_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	srcPath := filepath.Join(srcDir, "terraform-provider-mirror_v1.0.0_linux_amd64")
+	if err := os.WriteFile(srcPath, []byte("mock binary content"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	newBuilder := func(opts ...Option) *Builder {
+		return New(srcDir, dstDir, append([]Option{WithNamespace("example"), WithNetworkMirror("registry.example.com"), WithNetworkMirrorOnly(true)}, opts...)...)
+	}
+	if err := newBuilder().Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	mirrorDir := filepath.Join(dstDir, "registry.example.com", "example", "mirror")
+	mirrorVersion, err := file.ReadMirrorVersion(filepath.Join(mirrorDir, "1.0.0.json"))
+	if err != nil {
+		t.Fatalf("ReadMirrorVersion() error = %v", err)
+	}
+	archive, ok := mirrorVersion.Archives["linux_amd64"]
+	if !ok {
+		t.Fatalf("Archives = %v, want linux_amd64", mirrorVersion.Archives)
+	}
+	if archive.URL != "terraform-provider-mirror_1.0.0_linux_amd64.zip" {
+		t.Errorf("URL = %v, want terraform-provider-mirror_1.0.0_linux_amd64.zip", archive.URL)
+	}
+	if _, err := os.Stat(filepath.Join(mirrorDir, archive.URL)); err != nil {
+		t.Errorf("Zip file not created: %v", err)
+	}
+
+	for _, name := range []string{"v1", ".well-known"} {
+		if _, err := os.Stat(filepath.Join(dstDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was created in network mirror only mode", name)
+		}
+	}
+
+	t.Run("identical content", func(t *testing.T) {
+		if err := newBuilder().Build(); err != nil {
+			t.Errorf("Build() error = %v", err)
+		}
+	})
+
+	t.Run("different content", func(t *testing.T) {
+		if err := os.WriteFile(srcPath, []byte("changed binary content"), 0755); err != nil {
+			t.Fatalf("Failed to update test file: %v", err)
+		}
+		err := newBuilder().Build()
+		if err == nil || !strings.Contains(err.Error(), "different content") {
+			t.Errorf("Build() error = %v, want different content error", err)
+		}
+		if err := newBuilder(WithForce(true)).Build(); err != nil {
+			t.Errorf("Build() with force error = %v", err)
+		}
+	})
+
+	t.Run("without namespace", func(t *testing.T) {
+		err := New(srcDir, t.TempDir(), WithNetworkMirror("registry.example.com"), WithNetworkMirrorOnly(true)).Build()
+		if err == nil {
+			t.Error("Build() error = nil, want namespace error")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderModules verifies that module source directories and tarballs are
+// published to the module registry alongside providers.
+func TestBuilderModules(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_module_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_module_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	// Module source directory
+	moduleDir := filepath.Join(srcDir, "modules", "example-vpc-aws_v1.0.0")
+	if err := os.MkdirAll(moduleDir, 0755); err != nil {
+		t.Fatalf("Failed to create module directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte("# vpc"), 0644); err != nil {
+		t.Fatalf("Failed to create module file: %v", err)
+	}
+
+	// Module tarball, built from another directory
+	tarballSrcDir := filepath.Join(t.TempDir(), "vpc")
+	if err := os.MkdirAll(tarballSrcDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tarballSrcDir, "main.tf"), []byte("# vpc 1.10"), 0644); err != nil {
+		t.Fatalf("Failed to create module file: %v", err)
+	}
+	if err := file.CreateTarGzFromDir(tarballSrcDir, filepath.Join(srcDir, "example-vpc-aws_v1.10.0.tar.gz")); err != nil {
+		t.Fatalf("Failed to create module tarball: %v", err)
+	}
+
+	t.Run("requires site root", func(t *testing.T) {
+		if err := New(srcDir, dstDir).Build(); err == nil {
+			t.Error("Build() error = nil, want error")
+		}
+	})
+
+	b := New(srcDir, dstDir, WithSiteRoot(true))
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// Versions index
+	index, err := file.ReadModuleVersionsIndex(filepath.Join(dstDir, "v1", "modules", "example", "vpc", "aws", "versions", "index.json"))
+	if err != nil {
+		t.Fatalf("Failed to read module versions index: %v", err)
+	}
+	versions := index.Modules[0].Versions
+	if len(versions) != 2 || versions[0].Version != "1.10.0" || versions[1].Version != "1.0.0" {
+		t.Errorf("Module versions = %v, want [1.10.0 1.0.0]", versions)
+	}
+
+	// Download endpoints and archives
+	for _, version := range []string{"1.0.0", "1.10.0"} {
+		data, err := os.ReadFile(filepath.Join(dstDir, "v1", "modules", "example", "vpc", "aws", version, "download", "index.json"))
+		if err != nil {
+			t.Fatalf("Failed to read module download index: %v", err)
+		}
+		var downloadIndex file.ModuleDownloadIndex
+		if err := json.Unmarshal(data, &downloadIndex); err != nil {
+			t.Fatalf("Failed to parse module download index: %v", err)
+		}
+		expected := "/v1/modules/example/vpc/aws/" + version + "/example-vpc-aws_v" + version + ".tar.gz"
+		if downloadIndex.Location != expected {
+			t.Errorf("Location = %q, want %q", downloadIndex.Location, expected)
+		}
+		if _, err := os.Stat(filepath.Join(dstDir, filepath.FromSlash(expected))); err != nil {
+			t.Errorf("Module archive not created: %v", err)
+		}
+	}
+
+	// Service discovery document declares both registries
+	data, err := os.ReadFile(filepath.Join(dstDir, ".well-known", "terraform.json"))
+	if err != nil {
+		t.Fatalf("Failed to read service discovery document: %v", err)
+	}
+	var document map[string]string
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Failed to parse service discovery document: %v", err)
+	}
+	if document["modules.v1"] != "/v1/modules/" || document["providers.v1"] != "/v1/providers/" {
+		t.Errorf("Service discovery document = %v", document)
+	}
+
+	// Rebuilding identical modules is a no-op, changed ones are rejected
+	if err := b.Build(); err != nil {
+		t.Fatalf("Second Build() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte("# changed"), 0644); err != nil {
+		t.Fatalf("Failed to update module file: %v", err)
+	}
+	if err := b.Build(); err == nil {
+		t.Error("Build() with changed module error = nil, want error")
+	}
+	if err := New(srcDir, dstDir, WithSiteRoot(true), WithForce(true)).Build(); err != nil {
+		t.Errorf("Build() in force mode error = %v", err)
+	}
+}
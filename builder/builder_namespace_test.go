@@ -0,0 +1,116 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBuilderMultipleNamespaces verifies that a single SRC tree can populate
+// several namespaces under a common registry root.
+func TestBuilderMultipleNamespaces(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_namespace_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	files := []string{
+		filepath.Join("team-a", "terraform-provider-alpha_v1.0.0_linux_amd64"),
+		filepath.Join("team-b", "nested", "terraform-provider-beta_v1.0.0_linux_amd64"),
+		filepath.Join("shared", "builds", "terraform-provider-gamma_v1.0.0_linux_amd64"),
+		filepath.Join("team-a", "terraform-provider-delta_v1.0.0_linux_amd64"),
+		"terraform-provider-top_v1.0.0_linux_amd64",
+	}
+	for _, name := range files {
+		path := filepath.Join(srcDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("mock binary content "+name), 0755); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name: "namespace from directory",
+			opts: []Option{
+				WithNamespaceFromDir(true),
+				WithNamespace("default"),
+			},
+			expected: []string{
+				filepath.Join("team-a", "alpha"),
+				filepath.Join("team-b", "beta"),
+				filepath.Join("shared", "gamma"),
+				filepath.Join("team-a", "delta"),
+				filepath.Join("default", "top"),
+			},
+		},
+		{
+			name: "namespace config",
+			opts: []Option{
+				WithNamespaceConfig(&NamespaceConfig{
+					Directories: map[string]string{
+						"team-a":        "alpha-team",
+						"shared":        "common",
+						"shared/builds": "builds",
+					},
+					Providers: map[string]string{
+						"delta": "delta-team",
+					},
+				}),
+				WithNamespace("default"),
+			},
+			expected: []string{
+				filepath.Join("alpha-team", "alpha"),
+				filepath.Join("default", "beta"),
+				filepath.Join("builds", "gamma"),
+				filepath.Join("delta-team", "delta"),
+				filepath.Join("default", "top"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dstDir, err := os.MkdirTemp("", "builder_namespace_test_dst")
+			if err != nil {
+				t.Fatalf("Failed to create temporary destination directory: %v", err)
+			}
+			defer os.RemoveAll(dstDir)
+
+			if err := New(srcDir, dstDir, tt.opts...).Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			for _, base := range tt.expected {
+				indexPath := filepath.Join(dstDir, "v1", "providers", base, "versions", "index.json")
+				if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+					t.Errorf("Expected file not created: %s", indexPath)
+				}
+			}
+
+			if _, err := os.Stat(filepath.Join(dstDir, ".well-known", "terraform.json")); os.IsNotExist(err) {
+				t.Error("Service discovery document not created")
+			}
+		})
+	}
+
+	t.Run("undetermined namespace", func(t *testing.T) {
+		dstDir, err := os.MkdirTemp("", "builder_namespace_test_dst")
+		if err != nil {
+			t.Fatalf("Failed to create temporary destination directory: %v", err)
+		}
+		defer os.RemoveAll(dstDir)
+
+		// The file at the top level of SRC has no namespace
+		if err := New(srcDir, dstDir, WithNamespaceFromDir(true)).Build(); err == nil {
+			t.Error("Build() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package builder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBuilderDryRun verifies that a dry run plans the actions on each version/platform without writing to DST.
+func TestBuilderDryRun(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_plan_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_plan_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	writeProvider := func(name, content string) string {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+
+	// Publish the first version
+	linuxPath := writeProvider("terraform-provider-plan_v1.0.0_linux_amd64", "linux content")
+	darwinPath := writeProvider("terraform-provider-plan_v1.0.0_darwin_arm64", "darwin content")
+	if err := New(srcDir, dstDir).Build(); err != nil {
+		t.Fatalf("First Build() error = %v", err)
+	}
+	before := snapshotDir(t, dstDir)
+
+	// Change one platform and add a new version
+	writeProvider("terraform-provider-plan_v1.0.0_darwin_arm64", "changed darwin content")
+	newPath := writeProvider("terraform-provider-plan_v1.1.0_linux_amd64", "new content")
+
+	var out bytes.Buffer
+	b := New(srcDir, dstDir, WithDryRun(true), WithWorkers(4))
+	b.out = &out
+	if err := b.Build(); err != nil {
+		t.Fatalf("Dry run Build() error = %v", err)
+	}
+
+	want := []PlanEntry{
+		{Kind: "provider", Name: "plan", Version: "1.0.0", OS: "darwin", Arch: "arm64", Action: PlanConflict, Source: darwinPath},
+		{Kind: "provider", Name: "plan", Version: "1.0.0", OS: "linux", Arch: "amd64", Action: PlanSkip, Source: linuxPath},
+		{Kind: "provider", Name: "plan", Version: "1.1.0", OS: "linux", Arch: "amd64", Action: PlanAdd, Source: newPath},
+	}
+	plan := b.Plan()
+	if !reflect.DeepEqual(plan.Entries, want) {
+		t.Errorf("Plan().Entries = %+v, want %+v", plan.Entries, want)
+	}
+	if !plan.HasChanges() || !plan.HasConflicts() {
+		t.Errorf("HasChanges() = %v, HasConflicts() = %v, want true, true", plan.HasChanges(), plan.HasConflicts())
+	}
+	if out.Len() != 0 {
+		t.Errorf("Dry run wrote progress messages: %q", out.String())
+	}
+
+	var text bytes.Buffer
+	plan.WriteText(&text)
+	if !strings.Contains(text.String(), "Plan: 1 to add, 0 to republish, 0 to remove, 1 to skip, 1 conflicts.") {
+		t.Errorf("WriteText() = %q, want the summary", text.String())
+	}
+
+	if after := snapshotDir(t, dstDir); !reflect.DeepEqual(before, after) {
+		t.Errorf("DST changed by a dry run:\nbefore: %v\nafter:  %v", keys(before), keys(after))
+	}
+
+	// The signing key is validated as versions are to be added
+	t.Setenv("TFREGBUILDER_GPG_KEY", "")
+	if err := New(srcDir, dstDir, WithDryRun(true)).Build(); err == nil || !strings.Contains(err.Error(), "signing key error") {
+		t.Errorf("Dry run Build() without key error = %v, want signing key error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// assertProtocols verifies the protocols in the versions index and in the download indexes of the given platforms.
+func assertProtocols(t *testing.T, dstDir, providerType, version string, platforms [][2]string, want []string) {
+	t.Helper()
+
+	index, err := file.ReadVersionsIndex(filepath.Join(dstDir, providerType, "versions", "index.json"), providerType)
+	if err != nil {
+		t.Fatalf("Failed to read versions index: %v", err)
+	}
+	ver := index.FindVersion(version)
+	if ver == nil {
+		t.Fatalf("Version %s not found in versions index", version)
+	}
+	if !file.EqualProtocols(ver.Protocols, want) {
+		t.Errorf("Versions index protocols = %v, want %v", ver.Protocols, want)
+	}
+
+	for _, platform := range platforms {
+		downloadIndexPath := filepath.Join(dstDir, providerType, version, "download", platform[0], platform[1], "index.json")
+		downloadIndex, err := file.ReadDownloadIndex(downloadIndexPath)
+		if err != nil {
+			t.Fatalf("Failed to read download index: %v", err)
+		}
+		if !file.EqualProtocols(downloadIndex.Protocols, want) {
+			t.Errorf("Download index protocols for %s/%s = %v, want %v", platform[0], platform[1], downloadIndex.Protocols, want)
+		}
+	}
+}
+
+// TestBuilderDetectsProtocols verifies that protocol versions are read from
+// registry manifests and applied consistently to all platforms of a version.
+func TestBuilderDetectsProtocols(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_protocols_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_protocols_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	// goreleaser style manifest next to the binaries
+	files := map[string]string{
+		"terraform-provider-proto_v1.0.0_linux_amd64":    "linux binary",
+		"terraform-provider-proto_v1.0.0_darwin_arm64":   "darwin binary",
+		"terraform-provider-proto_1.0.0_manifest.json":   `{"version": 1, "metadata": {"protocol_versions": ["5.0"]}}`,
+		"terraform-provider-default_v1.0.0_linux_amd64":  "default binary",
+		"terraform-provider-override_v1.0.0_linux_amd64": "override binary",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	b := New(srcDir, dstDir, WithProtocols(map[string][]string{
+		"override": {"5.0", "6.0"},
+	}))
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	assertProtocols(t, dstDir, "proto", "1.0.0", [][2]string{{"linux", "amd64"}, {"darwin", "arm64"}}, []string{"5.0"})
+	assertProtocols(t, dstDir, "default", "1.0.0", [][2]string{{"linux", "amd64"}}, []string{"6.0"})
+	assertProtocols(t, dstDir, "override", "1.0.0", [][2]string{{"linux", "amd64"}}, []string{"5.0", "6.0"})
+
+	// A new platform without a manifest inherits the protocols of the existing version
+	if err := os.Remove(filepath.Join(srcDir, "terraform-provider-proto_1.0.0_manifest.json")); err != nil {
+		t.Fatalf("Failed to remove manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "terraform-provider-proto_v1.0.0_windows_amd64.exe"), []byte("windows binary"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := b.Build(); err != nil {
+		t.Fatalf("Second Build() error = %v", err)
+	}
+	assertProtocols(t, dstDir, "proto", "1.0.0", [][2]string{{"linux", "amd64"}, {"darwin", "arm64"}, {"windows", "amd64"}}, []string{"5.0"})
+
+	// An override fixes the protocols of already published platforms
+	b = New(srcDir, dstDir, WithProtocols(map[string][]string{
+		"default": {"5.0"},
+	}))
+	if err := b.Build(); err != nil {
+		t.Fatalf("Third Build() error = %v", err)
+	}
+	assertProtocols(t, dstDir, "default", "1.0.0", [][2]string{{"linux", "amd64"}}, []string{"5.0"})
+}
@@ -0,0 +1,148 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
+)
+
+// TestBuilderPrune verifies that the versions not kept by the prune rules are previewed by a dry run
+// and then removed.
+func TestBuilderPrune(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_prune_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_prune_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	for _, version := range []string{"0.9.0", "1.0.0", "1.1.0", "1.2.0", "2.0.0-beta.1", "2.0.0-beta.2"} {
+		name := "terraform-provider-prune_v" + version + "_linux_amd64"
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := New(srcDir, dstDir).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// The first prerelease was published long ago
+	versionsIndexPath := filepath.Join(dstDir, "prune", "versions", "index.json")
+	versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, "prune")
+	if err != nil {
+		t.Fatalf("Failed to read versions index: %v", err)
+	}
+	oldTime := time.Now().Add(-40 * 24 * time.Hour).UTC().Truncate(time.Second)
+	versionsIndex.FindVersion("2.0.0-beta.1").PublishedAt = &oldTime
+	if err := file.WriteVersionsIndex(versionsIndexPath, versionsIndex); err != nil {
+		t.Fatalf("Failed to write versions index: %v", err)
+	}
+
+	// Republishing does not change the publish time
+	name := "terraform-provider-prune_v2.0.0-beta.1_linux_amd64"
+	if err := os.WriteFile(filepath.Join(srcDir, name), []byte("republished"), 0755); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+	if err := New(srcDir, dstDir, WithForce(true)).Build(); err != nil {
+		t.Fatalf("Build() republishing error = %v", err)
+	}
+	versionsIndex, err = file.ReadVersionsIndex(versionsIndexPath, "prune")
+	if err != nil {
+		t.Fatalf("Failed to read versions index: %v", err)
+	}
+	if publishedAt := versionsIndex.FindVersion("2.0.0-beta.1").PublishedAt; publishedAt == nil || !publishedAt.Equal(oldTime) {
+		t.Errorf("Publish time after republishing = %v, want %v", publishedAt, oldTime)
+	}
+
+	keep, err := semver.ParseConstraints("~> 1.0.0")
+	if err != nil {
+		t.Fatalf("ParseConstraints() error = %v", err)
+	}
+	rules := &PruneRules{
+		KeepStable:         2,
+		KeepPrereleasesFor: 30 * 24 * time.Hour,
+		Keep:               []semver.Constraints{keep},
+	}
+
+	// Preview
+	before := snapshotDir(t, dstDir)
+	b := New("", dstDir, WithPrune(rules), WithDryRun(true))
+	if err := b.Prune(); err != nil {
+		t.Fatalf("Dry run Prune() error = %v", err)
+	}
+	var planned []string
+	for _, entry := range b.Plan().Entries {
+		if entry.Action != PlanRemove {
+			t.Errorf("Plan entry %+v, want remove", entry)
+		}
+		planned = append(planned, entry.Version)
+	}
+	if want := []string{"0.9.0", "2.0.0-beta.1"}; !reflect.DeepEqual(planned, want) {
+		t.Errorf("Planned versions = %v, want %v", planned, want)
+	}
+	if after := snapshotDir(t, dstDir); !reflect.DeepEqual(before, after) {
+		t.Errorf("DST changed by a dry run:\nbefore: %v\nafter:  %v", keys(before), keys(after))
+	}
+
+	// Prune
+	if err := New("", dstDir, WithPrune(rules)).Prune(); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	versionsIndex, err = file.ReadVersionsIndex(versionsIndexPath, "prune")
+	if err != nil {
+		t.Fatalf("Failed to read versions index: %v", err)
+	}
+	var versions []string
+	for _, ver := range versionsIndex.Versions {
+		versions = append(versions, ver.Version)
+	}
+	if want := []string{"2.0.0-beta.2", "1.2.0", "1.1.0", "1.0.0"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("Versions after Prune() = %v, want %v", versions, want)
+	}
+	for _, version := range []string{"0.9.0", "2.0.0-beta.1"} {
+		if _, err := os.Stat(filepath.Join(dstDir, "prune", version)); !os.IsNotExist(err) {
+			t.Errorf("Directory of pruned version %s is not removed: %v", version, err)
+		}
+	}
+}
+
+// TestBuilderPruneWithoutSigningKey verifies that whole versions are pruned in the per-version SHA256SUMS layout
+// without signing, as nothing of the versions is left to publish.
+func TestBuilderPruneWithoutSigningKey(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		for _, platform := range []string{"linux_amd64", "darwin_arm64"} {
+			name := "terraform-provider-prune_v" + version + "_" + platform
+			if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+		}
+	}
+	if err := New(srcDir, dstDir, WithVersionSHASums(true)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	t.Setenv("TFREGBUILDER_GPG_KEY", "")
+	t.Setenv("TFREGBUILDER_GPG_KEY_FILE", "")
+	if err := New("", dstDir, WithPrune(&PruneRules{KeepStable: 1})).Prune(); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "prune", "1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("Directory of pruned version 1.0.0 is not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "prune", "1.1.0", "download", "terraform-provider-prune_1.1.0_SHA256SUMS.sig")); err != nil {
+		t.Errorf("Signature of the kept version is removed: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderReindex verifies that versions indexes are rebuilt from the download directories,
+// reporting drift, and that builds recover corrupted indexes with WithReindex.
+func TestBuilderReindex(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_reindex_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_reindex_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	writeProvider := func(name string) {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	versionsIndexPath := filepath.Join(dstDir, "reidx", "versions", "index.json")
+	readIndex := func() *file.VersionsIndex {
+		versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, "reidx")
+		if err != nil {
+			t.Fatalf("Failed to read versions index: %v", err)
+		}
+		return versionsIndex
+	}
+
+	writeProvider("terraform-provider-reidx_v1.0.0_linux_amd64")
+	writeProvider("terraform-provider-reidx_v1.0.0_darwin_arm64")
+	writeProvider("terraform-provider-reidx_v1.1.0_linux_amd64")
+	if err := New(srcDir, dstDir, WithProtocols(map[string][]string{"reidx": {"6.0"}})).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if err := New("", dstDir).SetWarnings("", "reidx", []string{"Deprecated."}); err != nil {
+		t.Fatalf("SetWarnings() error = %v", err)
+	}
+	want := readIndex()
+
+	// Drift between the index and the download directories is reported and fixed
+	drifted := readIndex()
+	drifted.RemovePlatform("1.0.0", "darwin", "arm64")
+	drifted.AddVersion("0.9.0", "linux", "amd64", []string{"5.0"})
+	if err := file.WriteVersionsIndex(versionsIndexPath, drifted); err != nil {
+		t.Fatalf("Failed to write versions index: %v", err)
+	}
+	b := New("", dstDir)
+	var out bytes.Buffer
+	b.out = &out
+	if err := b.Reindex("", ""); err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+	for _, drift := range []string{
+		"version 1.0.0 for darwin/arm64 was missing",
+		"version 0.9.0 has no download directories",
+	} {
+		if !strings.Contains(out.String(), drift) {
+			t.Errorf("Reindex() output does not report %q:\n%s", drift, out.String())
+		}
+	}
+	if got := readIndex(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Reindexed versions index = %+v, want %+v", got, want)
+	}
+
+	// A lost index is rebuilt with the protocols, but without the publish times
+	for i := range want.Versions {
+		want.Versions[i].PublishedAt = nil
+	}
+	if err := os.Remove(versionsIndexPath); err != nil {
+		t.Fatalf("Failed to remove versions index: %v", err)
+	}
+	if err := New("", dstDir).Reindex("", "reidx"); err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+	if got := readIndex(); !reflect.DeepEqual(got.Versions, want.Versions) {
+		t.Errorf("Rebuilt versions = %+v, want %+v", got.Versions, want.Versions)
+	}
+	if err := New("", dstDir).Reindex("", "missing"); err == nil {
+		t.Errorf("Reindex() of an unpublished provider succeeded, want error")
+	}
+
+	// Builds fail on a corrupted index unless recovering it
+	if err := os.WriteFile(versionsIndexPath, []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt versions index: %v", err)
+	}
+	writeProvider("terraform-provider-reidx_v1.2.0_linux_amd64")
+	if err := New(srcDir, dstDir).Build(); err == nil {
+		t.Fatalf("Build() with a corrupted versions index succeeded, want error")
+	}
+	if err := New(srcDir, dstDir, WithReindex(true)).Build(); err != nil {
+		t.Fatalf("Build() with WithReindex error = %v", err)
+	}
+	got := readIndex()
+	if len(got.Versions) != 3 || got.FindVersion("1.2.0") == nil || len(got.FindVersion("1.0.0").Platforms) != 2 {
+		t.Errorf("Recovered versions = %+v, want 1.0.0, 1.1.0 and 1.2.0", got.Versions)
+	}
+	if ver := got.FindVersion("1.1.0"); ver == nil || !reflect.DeepEqual(ver.Protocols, []string{"6.0"}) {
+		t.Errorf("Recovered version 1.1.0 = %+v, want protocols [6.0]", ver)
+	}
+}
@@ -0,0 +1,203 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderRemove verifies that platforms and versions are unpublished, updating the versions index
+// and the shared SHA256SUMS file, and cleaning up empty directories.
+func TestBuilderRemove(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_remove_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_remove_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	for _, name := range []string{
+		"terraform-provider-rm_v1.0.0_linux_amd64",
+		"terraform-provider-rm_v1.0.0_darwin_arm64",
+		"terraform-provider-rm_v1.1.0_linux_amd64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := New(srcDir, dstDir, WithVersionSHASums(true)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// Remove a platform
+	if err := New("", dstDir).Remove("", "rm", "1.0.0", "darwin", "arm64"); err != nil {
+		t.Fatalf("Remove() platform error = %v", err)
+	}
+	versionsIndex, err := file.ReadVersionsIndex(filepath.Join(dstDir, "rm", "versions", "index.json"), "rm")
+	if err != nil {
+		t.Fatalf("Failed to read versions index: %v", err)
+	}
+	ver := versionsIndex.FindVersion("1.0.0")
+	if ver == nil || len(ver.Platforms) != 1 || ver.Platforms[0].OS != "linux" {
+		t.Errorf("Version 1.0.0 after removing darwin/arm64 = %+v, want only linux/amd64", ver)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "rm", "1.0.0", "download", "darwin")); !os.IsNotExist(err) {
+		t.Errorf("Download directory of darwin is not removed: %v", err)
+	}
+	sums, err := file.ReadSHA256SumsFile(filepath.Join(dstDir, "rm", "1.0.0", "download", "terraform-provider-rm_1.0.0_SHA256SUMS"))
+	if err != nil {
+		t.Fatalf("Failed to read SHA256SUMS: %v", err)
+	}
+	if _, ok := sums["terraform-provider-rm_1.0.0_darwin_arm64.zip"]; ok || len(sums) != 2 {
+		t.Errorf("SHA256SUMS after removing darwin/arm64 = %v, want only linux/amd64 and the manifest", sums)
+	}
+
+	// Remove a whole version
+	if err := New("", dstDir).Remove("", "rm", "1.0.0", "", ""); err != nil {
+		t.Fatalf("Remove() version error = %v", err)
+	}
+	versionsIndex, err = file.ReadVersionsIndex(filepath.Join(dstDir, "rm", "versions", "index.json"), "rm")
+	if err != nil {
+		t.Fatalf("Failed to read versions index: %v", err)
+	}
+	if len(versionsIndex.Versions) != 1 || versionsIndex.Versions[0].Version != "1.1.0" {
+		t.Errorf("Versions after removing 1.0.0 = %+v, want only 1.1.0", versionsIndex.Versions)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "rm", "1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("Version directory is not removed: %v", err)
+	}
+
+	// Nothing is left behind by the transactions
+	for path := range snapshotDir(t, dstDir) {
+		if strings.Contains(path, ".rollback-") {
+			t.Errorf("Backup is left: %s", path)
+		}
+	}
+
+	// Removing an unpublished version fails
+	if err := New("", dstDir).Remove("", "rm", "1.0.0", "", ""); err == nil {
+		t.Error("Remove() unpublished version error = nil, want error")
+	}
+}
+
+// TestBuilderRemoveVersionWithoutSigningKey verifies that a whole version is removed in the per-version
+// SHA256SUMS layout without signing, as nothing of the version is left to publish.
+func TestBuilderRemoveVersionWithoutSigningKey(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+
+	for _, name := range []string{
+		"terraform-provider-rm_v1.0.0_linux_amd64",
+		"terraform-provider-rm_v1.0.0_linux_arm64",
+		"terraform-provider-rm_v1.0.0_darwin_arm64",
+		"terraform-provider-rm_v1.1.0_linux_amd64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := New(srcDir, dstDir, WithVersionSHASums(true)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	t.Setenv("TFREGBUILDER_GPG_KEY", "")
+	t.Setenv("TFREGBUILDER_GPG_KEY_FILE", "")
+
+	// Removing a platform requires the signing key, as the SHA256SUMS file is signed again
+	if err := New("", dstDir).Remove("", "rm", "1.0.0", "darwin", "arm64"); err == nil {
+		t.Error("Remove() platform error = nil, want signing error")
+	}
+
+	if err := New("", dstDir).Remove("", "rm", "1.0.0", "", ""); err != nil {
+		t.Fatalf("Remove() version error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "rm", "1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("Version directory is not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "rm", "1.1.0", "download", "terraform-provider-rm_1.1.0_SHA256SUMS.sig")); err != nil {
+		t.Errorf("Signature of the other version is removed: %v", err)
+	}
+}
+
+// TestBuilderRemoveMirrors verifies that removed platforms are also removed from the network mirror
+// and the filesystem mirror.
+func TestBuilderRemoveMirrors(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	fsMirrorDir := t.TempDir()
+
+	for _, name := range []string{
+		"terraform-provider-rm_v1.0.0_linux_amd64",
+		"terraform-provider-rm_v1.0.0_darwin_arm64",
+		"terraform-provider-rm_v1.1.0_linux_amd64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	mirrorOpts := []Option{
+		WithNamespace("example"),
+		WithNetworkMirror("registry.example.com"),
+		WithFilesystemMirror(&FilesystemMirror{Dir: fsMirrorDir, Hostname: "registry.example.com"}),
+	}
+	if err := New(srcDir, dstDir, mirrorOpts...).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	mirrorDir := filepath.Join(dstDir, "registry.example.com", "example", "rm")
+	fsProviderDir := filepath.Join(fsMirrorDir, "registry.example.com", "example", "rm")
+
+	// Remove a platform
+	if err := New("", dstDir, mirrorOpts...).Remove("", "rm", "1.0.0", "darwin", "arm64"); err != nil {
+		t.Fatalf("Remove() platform error = %v", err)
+	}
+	mirrorVersion, err := file.ReadMirrorVersion(filepath.Join(mirrorDir, "1.0.0.json"))
+	if err != nil {
+		t.Fatalf("ReadMirrorVersion() error = %v", err)
+	}
+	if _, ok := mirrorVersion.Archives["darwin_arm64"]; ok || len(mirrorVersion.Archives) != 1 {
+		t.Errorf("Archives after removing darwin/arm64 = %v, want only linux_amd64", mirrorVersion.Archives)
+	}
+	if _, err := os.Stat(filepath.Join(fsProviderDir, "terraform-provider-rm_1.0.0_darwin_arm64.zip")); !os.IsNotExist(err) {
+		t.Errorf("Filesystem mirror package of darwin/arm64 is not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(fsProviderDir, "terraform-provider-rm_1.0.0_linux_amd64.zip")); err != nil {
+		t.Errorf("Filesystem mirror package of linux/amd64 is removed: %v", err)
+	}
+
+	// Remove a whole version
+	if err := New("", dstDir, mirrorOpts...).Remove("", "rm", "1.0.0", "", ""); err != nil {
+		t.Fatalf("Remove() version error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(mirrorDir, "1.0.0.json")); !os.IsNotExist(err) {
+		t.Errorf("Network mirror version file is not removed: %v", err)
+	}
+	mirrorIndex, err := file.ReadMirrorIndex(filepath.Join(mirrorDir, "index.json"))
+	if err != nil {
+		t.Fatalf("ReadMirrorIndex() error = %v", err)
+	}
+	if _, ok := mirrorIndex.Versions["1.1.0"]; !ok || len(mirrorIndex.Versions) != 1 {
+		t.Errorf("Network mirror versions after removing 1.0.0 = %v, want [1.1.0]", mirrorIndex.Versions)
+	}
+	if _, err := os.Stat(filepath.Join(fsProviderDir, "terraform-provider-rm_1.0.0_linux_amd64.zip")); !os.IsNotExist(err) {
+		t.Errorf("Filesystem mirror package of 1.0.0 is not removed: %v", err)
+	}
+
+	// Removing the last version removes the provider from the mirrors
+	if err := New("", dstDir, mirrorOpts...).Remove("", "rm", "1.1.0", "", ""); err != nil {
+		t.Fatalf("Remove() last version error = %v", err)
+	}
+	for _, dir := range []string{mirrorDir, fsProviderDir} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Mirror directory %s is not removed: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderResign verifies that signatures and signing keys are replaced with the ones of a new key,
+// optionally keeping the old keys, without changing the zip files.
+func TestBuilderResign(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_resign_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_resign_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	for _, name := range []string{
+		"terraform-provider-resign_v1.0.0_linux_amd64",
+		"terraform-provider-resign_v1.0.0_darwin_arm64",
+		"terraform-provider-resign_v1.1.0_linux_amd64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := New(srcDir, dstDir, WithVersionSHASums(true)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	oldKey, err := file.EnvSigner{}.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey() error = %v", err)
+	}
+	zipPath := filepath.Join(dstDir, "resign", "1.0.0", "download", "linux", "amd64", "terraform-provider-resign_1.0.0_linux_amd64.zip")
+	zipHash, err := file.CalculateSHA256(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to calculate hash: %v", err)
+	}
+
+	// Rotate the signing key
+	key, err := crypto.PGP().KeyGeneration().AddUserId("terraform-registry-builder-rotated", "rotated@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate GPG key: %v", err)
+	}
+	armored, err := key.Armor()
+	if err != nil {
+		t.Fatalf("Failed to armor GPG key: %v", err)
+	}
+	fingerprint := key.GetFingerprint()
+	t.Setenv("TFREGBUILDER_GPG_KEY", armored)
+	t.Setenv("TFREGBUILDER_GPG_PASSPHRASE", "")
+	t.Setenv("TFREGBUILDER_GPG_ID", fingerprint[len(fingerprint)-16:])
+	newKey, err := file.EnvSigner{}.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey() error = %v", err)
+	}
+
+	assertSigningKeys := func(want ...*file.GPGPublicKey) {
+		t.Helper()
+		downloadIndex, err := file.ReadDownloadIndex(filepath.Join(dstDir, "resign", "1.0.0", "download", "darwin", "arm64", "index.json"))
+		if err != nil {
+			t.Fatalf("Failed to read download index: %v", err)
+		}
+		keys := downloadIndex.SigningKeys.GPGPublicKeys
+		if len(keys) != len(want) {
+			t.Fatalf("Signing keys = %d, want %d", len(keys), len(want))
+		}
+		for i := range want {
+			if keys[i].KeyID != want[i].KeyID {
+				t.Errorf("Signing key %d = %s, want %s", i, keys[i].KeyID, want[i].KeyID)
+			}
+		}
+
+		report, err := New("", dstDir).Verify()
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if report.ProblemCount() != 0 {
+			t.Errorf("Verify() after resigning = %+v, want no problems", report.Providers)
+		}
+
+		shaSumsPath := filepath.Join(dstDir, "resign", "1.0.0", "download", "terraform-provider-resign_1.0.0_SHA256SUMS")
+		if err := file.VerifySignature(shaSumsPath, shaSumsPath+".sig", []file.GPGPublicKey{*newKey}); err != nil {
+			t.Errorf("SHA256SUMS is not signed with the new key: %v", err)
+		}
+	}
+
+	// Old keys are kept during the transition
+	if err := New("", dstDir).Resign(true); err != nil {
+		t.Fatalf("Resign() keeping old keys error = %v", err)
+	}
+	assertSigningKeys(newKey, oldKey)
+
+	// Old keys are dropped after the transition
+	if err := New("", dstDir).Resign(false); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+	assertSigningKeys(newKey)
+
+	if hash, err := file.CalculateSHA256(zipPath); err != nil || hash != zipHash {
+		t.Errorf("Zip file hash after resigning = %s, %v, want %s", hash, err, zipHash)
+	}
+}
@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderDownloadURL verifies that download indexes refer to the files with absolute URLs
+// built with the template, and that they can be rewritten afterwards.
+func TestBuilderDownloadURL(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_rewrite_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_rewrite_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	for _, name := range []string{
+		"terraform-provider-cdn_v1.0.0_linux_amd64",
+		"terraform-provider-cdn_v1.0.0_darwin_arm64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("mock binary content "+name), 0755); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	template, err := ParseDownloadURLTemplate("https://cdn.example.com/{namespace}/{type}/{version}/{os}_{arch}/{filename}")
+	if err != nil {
+		t.Fatalf("ParseDownloadURLTemplate() error = %v", err)
+	}
+	if err := New(srcDir, dstDir, WithNamespace("example"), WithDownloadURL(template)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	downloadIndexPath := filepath.Join(dstDir, "v1", "providers", "example", "cdn", "1.0.0", "download", "linux", "amd64", "index.json")
+	readIndex := func(t *testing.T) *file.DownloadIndex {
+		index, err := file.ReadDownloadIndex(downloadIndexPath)
+		if err != nil {
+			t.Fatalf("Failed to read download index: %v", err)
+		}
+		return index
+	}
+
+	index := readIndex(t)
+	if want := "https://cdn.example.com/example/cdn/1.0.0/linux_amd64/terraform-provider-cdn_v1.0.0_linux_amd64.zip"; index.DownloadURL != want {
+		t.Errorf("DownloadURL = %v, want %v", index.DownloadURL, want)
+	}
+	if want := "https://cdn.example.com/example/cdn/1.0.0/linux_amd64/terraform-provider-cdn_v1.0.0_linux_amd64_SHA256SUMS.sig"; index.ShasumsSignatureURL != want {
+		t.Errorf("ShasumsSignatureURL = %v, want %v", index.ShasumsSignatureURL, want)
+	}
+
+	t.Run("rewrite to base URL", func(t *testing.T) {
+		base, err := ParseDownloadURLTemplate("https://bucket.example.com/registry")
+		if err != nil {
+			t.Fatalf("ParseDownloadURLTemplate() error = %v", err)
+		}
+		if err := New("", dstDir, WithNamespace("example"), WithDownloadURL(base)).RewriteURLs(); err != nil {
+			t.Fatalf("RewriteURLs() error = %v", err)
+		}
+
+		index := readIndex(t)
+		if want := "https://bucket.example.com/registry/v1/providers/example/cdn/1.0.0/download/linux/amd64/terraform-provider-cdn_v1.0.0_linux_amd64.zip"; index.DownloadURL != want {
+			t.Errorf("DownloadURL = %v, want %v", index.DownloadURL, want)
+		}
+	})
+
+	t.Run("rewrite to relative URLs", func(t *testing.T) {
+		if err := New("", dstDir, WithNamespace("example")).RewriteURLs(); err != nil {
+			t.Fatalf("RewriteURLs() error = %v", err)
+		}
+
+		index := readIndex(t)
+		if want := "terraform-provider-cdn_v1.0.0_linux_amd64.zip"; index.DownloadURL != want {
+			t.Errorf("DownloadURL = %v, want %v", index.DownloadURL, want)
+		}
+		if want := "terraform-provider-cdn_v1.0.0_linux_amd64_SHA256SUMS"; index.ShasumsURL != want {
+			t.Errorf("ShasumsURL = %v, want %v", index.ShasumsURL, want)
+		}
+	})
+}
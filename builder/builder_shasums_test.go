@@ -0,0 +1,198 @@
+package builder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderVersionSHASums verifies that a single SHA256SUMS file per version lists all platforms
+// and that every platform's download index points to it.
+func TestBuilderVersionSHASums(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_shasums_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_shasums_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	writeSrc := func(t *testing.T, name string) {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("mock binary content "+name), 0755); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	// The first platform is published with the per-platform layout
+	writeSrc(t, "terraform-provider-sums_v1.0.0_linux_amd64")
+	if err := New(srcDir, dstDir).Build(); err != nil {
+		t.Fatalf("First Build() error = %v", err)
+	}
+
+	// More platforms are published with the per-version layout
+	writeSrc(t, "terraform-provider-sums_v1.0.0_darwin_arm64")
+	writeSrc(t, "terraform-provider-sums_v1.0.0_windows_amd64.exe")
+	b := New(srcDir, dstDir, WithVersionSHASums(true))
+	if err := b.Build(); err != nil {
+		t.Fatalf("Second Build() error = %v", err)
+	}
+
+	downloadDir := filepath.Join(dstDir, "sums", "1.0.0", "download")
+	shaSumsPath := filepath.Join(downloadDir, "terraform-provider-sums_1.0.0_SHA256SUMS")
+	if _, err := os.Stat(shaSumsPath + ".sig"); err != nil {
+		t.Errorf("Signature of the shared SHA256SUMS file not created: %v", err)
+	}
+
+	sums, err := file.ReadSHA256SumsFile(shaSumsPath)
+	if err != nil {
+		t.Fatalf("Failed to read shared SHA256SUMS file: %v", err)
+	}
+
+	// The zip files and the registry manifest are listed the way upstream releases do
+	platforms := [][2]string{{"linux", "amd64"}, {"darwin", "arm64"}, {"windows", "amd64"}}
+	if len(sums) != len(platforms)+1 {
+		t.Errorf("Shared SHA256SUMS file has %d entries, want %d", len(sums), len(platforms)+1)
+	}
+	manifestHash, err := file.CalculateSHA256(filepath.Join(downloadDir, "terraform-provider-sums_1.0.0_manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to calculate hash of the manifest: %v", err)
+	}
+	if sums["terraform-provider-sums_1.0.0_manifest.json"] != manifestHash {
+		t.Errorf("Shared SHA256SUMS entry for the manifest = %q, want %q", sums["terraform-provider-sums_1.0.0_manifest.json"], manifestHash)
+	}
+
+	for _, platform := range platforms {
+		platformDir := filepath.Join(downloadDir, platform[0], platform[1])
+		zipFileName := "terraform-provider-sums_1.0.0_" + platform[0] + "_" + platform[1] + ".zip"
+
+		hash, err := file.CalculateSHA256(filepath.Join(platformDir, zipFileName))
+		if err != nil {
+			t.Fatalf("Failed to calculate hash: %v", err)
+		}
+		if sums[zipFileName] != hash {
+			t.Errorf("Shared SHA256SUMS entry for %s = %q, want %q", zipFileName, sums[zipFileName], hash)
+		}
+
+		downloadIndex, err := file.ReadDownloadIndex(filepath.Join(platformDir, "index.json"))
+		if err != nil {
+			t.Fatalf("Failed to read download index: %v", err)
+		}
+		if downloadIndex.Filename != zipFileName {
+			t.Errorf("Filename for %s/%s = %q, want %q", platform[0], platform[1], downloadIndex.Filename, zipFileName)
+		}
+		if downloadIndex.ShasumsURL != "../../terraform-provider-sums_1.0.0_SHA256SUMS" {
+			t.Errorf("ShasumsURL for %s/%s = %q", platform[0], platform[1], downloadIndex.ShasumsURL)
+		}
+		if downloadIndex.ShasumsSignatureURL != "../../terraform-provider-sums_1.0.0_SHA256SUMS.sig" {
+			t.Errorf("ShasumsSignatureURL for %s/%s = %q", platform[0], platform[1], downloadIndex.ShasumsSignatureURL)
+		}
+
+		// Files of the per-platform layout are not left behind
+		for _, name := range []string{
+			"terraform-provider-sums_v1.0.0_" + platform[0] + "_" + platform[1] + ".zip",
+			"terraform-provider-sums_v1.0.0_" + platform[0] + "_" + platform[1] + "_SHA256SUMS",
+			"terraform-provider-sums_v1.0.0_" + platform[0] + "_" + platform[1] + "_SHA256SUMS.sig",
+		} {
+			if _, err := os.Stat(filepath.Join(platformDir, name)); !os.IsNotExist(err) {
+				t.Errorf("%s is left for %s/%s: %v", name, platform[0], platform[1], err)
+			}
+		}
+	}
+
+	// Building again is a no-op, compared against the shared file
+	if err := b.Build(); err != nil {
+		t.Fatalf("Third Build() error = %v", err)
+	}
+}
+
+// TestBuilderVersionSHASumsGoreleaser verifies that the per-version layout publishes files
+// byte-compatible with the checksums file of a goreleaser release.
+func TestBuilderVersionSHASumsGoreleaser(t *testing.T) {
+	platforms := [][2]string{{"linux", "amd64"}, {"darwin", "arm64"}}
+
+	assertSameContent := func(t *testing.T, path, wantPath string) {
+		t.Helper()
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		want, err := os.ReadFile(wantPath)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", wantPath, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from %s:\n%s\nwant:\n%s", filepath.Base(path), wantPath, got, want)
+		}
+	}
+
+	t.Run("released checksums file", func(t *testing.T) {
+		distDir := filepath.Join(t.TempDir(), "dist")
+		writeGoreleaserDist(t, distDir, platforms)
+
+		// Sign the checksums file with the signing key, as the sign pipe of goreleaser does
+		checksumsPath := filepath.Join(distDir, "terraform-provider-rel_1.2.3_SHA256SUMS")
+		if err := file.SignFile(file.EnvSigner{}, checksumsPath, checksumsPath+".sig"); err != nil {
+			t.Fatalf("Failed to sign checksums file: %v", err)
+		}
+		artifactsPath := filepath.Join(distDir, "artifacts.json")
+		artifacts, err := os.ReadFile(artifactsPath)
+		if err != nil {
+			t.Fatalf("Failed to read artifacts.json: %v", err)
+		}
+		artifacts = append(bytes.TrimSuffix(artifacts, []byte("]")), `,{"name": "terraform-provider-rel_1.2.3_SHA256SUMS.sig", "path": "dist/terraform-provider-rel_1.2.3_SHA256SUMS.sig", "type": "Signature"}]`...)
+		if err := os.WriteFile(artifactsPath, artifacts, 0644); err != nil {
+			t.Fatalf("Failed to write artifacts.json: %v", err)
+		}
+
+		dstDir := t.TempDir()
+		if err := New(distDir, dstDir, WithGoreleaser(true), WithVersionSHASums(true)).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		downloadDir := filepath.Join(dstDir, "rel", "1.2.3", "download")
+		assertSameContent(t, filepath.Join(downloadDir, "terraform-provider-rel_1.2.3_SHA256SUMS"), checksumsPath)
+		assertSameContent(t, filepath.Join(downloadDir, "terraform-provider-rel_1.2.3_SHA256SUMS.sig"), checksumsPath+".sig")
+		assertSameContent(t, filepath.Join(downloadDir, "terraform-provider-rel_1.2.3_manifest.json"), filepath.Join(distDir, "terraform-provider-rel_1.2.3_manifest.json"))
+		for _, platform := range platforms {
+			name := "terraform-provider-rel_1.2.3_" + platform[0] + "_" + platform[1] + ".zip"
+			assertSameContent(t, filepath.Join(downloadDir, platform[0], platform[1], name), filepath.Join(distDir, name))
+		}
+	})
+
+	t.Run("generated checksums file", func(t *testing.T) {
+		// The checksums file is not listed in the artifacts
+		distDir := filepath.Join(t.TempDir(), "dist")
+		writeGoreleaserDist(t, distDir, platforms)
+		artifactsPath := filepath.Join(distDir, "artifacts.json")
+		artifacts, err := os.ReadFile(artifactsPath)
+		if err != nil {
+			t.Fatalf("Failed to read artifacts.json: %v", err)
+		}
+		artifacts = bytes.Replace(artifacts, []byte(`,{"name": "terraform-provider-rel_1.2.3_SHA256SUMS", "path": "dist/terraform-provider-rel_1.2.3_SHA256SUMS", "type": "Checksum"}`), nil, 1)
+		if err := os.WriteFile(artifactsPath, artifacts, 0644); err != nil {
+			t.Fatalf("Failed to write artifacts.json: %v", err)
+		}
+
+		dstDir := t.TempDir()
+		if err := New(distDir, dstDir, WithGoreleaser(true), WithVersionSHASums(true)).Build(); err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+
+		shaSumsPath := filepath.Join(dstDir, "rel", "1.2.3", "download", "terraform-provider-rel_1.2.3_SHA256SUMS")
+		assertSameContent(t, shaSumsPath, filepath.Join(distDir, "terraform-provider-rel_1.2.3_SHA256SUMS"))
+		publicKey, err := file.EnvSigner{}.PublicKey()
+		if err != nil {
+			t.Fatalf("PublicKey() error = %v", err)
+		}
+		if err := file.VerifySignature(shaSumsPath, shaSumsPath+".sig", []file.GPGPublicKey{*publicKey}); err != nil {
+			t.Errorf("VerifySignature() error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package builder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// keySigner is a Signer with an in-memory key, counting the signatures.
+type keySigner struct {
+	key   *crypto.Key
+	signs atomic.Int32
+}
+
+func (s *keySigner) Sign(data []byte) ([]byte, error) {
+	s.signs.Add(1)
+	signer, err := crypto.PGP().Sign().SigningKey(s.key).Detached().New()
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(data, crypto.Bytes)
+}
+
+func (s *keySigner) PublicKey() (*file.GPGPublicKey, error) {
+	armored, err := s.key.GetArmoredPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &file.GPGPublicKey{KeyID: "KEYSIGNER", ASCIIArmor: armored}, nil
+}
+
+// TestBuilderSigner verifies that files are signed with the signer set with WithSigner.
+func TestBuilderSigner(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_signer_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_signer_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	for _, name := range []string{
+		"terraform-provider-signer_v1.0.0_linux_amd64",
+		"terraform-provider-signer_v1.0.0_darwin_arm64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	key, err := crypto.PGP().KeyGeneration().AddUserId("terraform-registry-builder-signer", "signer@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate GPG key: %v", err)
+	}
+	signer := &keySigner{key: key}
+
+	// The signing key in environment variables is not used
+	t.Setenv("TFREGBUILDER_GPG_KEY", "")
+	t.Setenv("TFREGBUILDER_GPG_KEY_FILE", "")
+	if err := New(srcDir, dstDir, WithSigner(signer)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if n := signer.signs.Load(); n != 2 {
+		t.Errorf("Signatures = %d, want 2", n)
+	}
+
+	downloadIndex, err := file.ReadDownloadIndex(filepath.Join(dstDir, "signer", "1.0.0", "download", "linux", "amd64", "index.json"))
+	if err != nil {
+		t.Fatalf("Failed to read download index: %v", err)
+	}
+	if keys := downloadIndex.SigningKeys.GPGPublicKeys; len(keys) != 1 || keys[0].KeyID != "KEYSIGNER" {
+		t.Errorf("Signing keys = %+v, want the key of the signer", keys)
+	}
+	report, err := New("", dstDir).Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if report.ProblemCount() != 0 {
+		t.Errorf("Verify() = %+v, want no problems", report.Providers)
+	}
+}
+
+// TestBuilderRemoteSigner verifies that files are signed by a signing service over HTTP.
+func TestBuilderRemoteSigner(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_remote_signer_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_remote_signer_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	name := "terraform-provider-remote_v1.0.0_linux_amd64"
+	if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	key, err := crypto.PGP().KeyGeneration().AddUserId("terraform-registry-builder-remote", "remote@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate GPG key: %v", err)
+	}
+	service := &keySigner{key: key}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/sign":
+			data, _ := io.ReadAll(r.Body)
+			signature, err := service.Sign(data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Write(signature)
+		case "/public-key":
+			armored, _ := key.GetArmoredPublicKey()
+			io.WriteString(w, armored)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	signer, err := file.NewRemoteSigner(server.URL+"/sign", server.URL+"/public-key", "token", "")
+	if err != nil {
+		t.Fatalf("NewRemoteSigner() error = %v", err)
+	}
+	t.Setenv("TFREGBUILDER_GPG_KEY", "")
+	t.Setenv("TFREGBUILDER_GPG_KEY_FILE", "")
+	if err := New(srcDir, dstDir, WithSigner(signer)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if n := service.signs.Load(); n != 1 {
+		t.Errorf("Signatures = %d, want 1", n)
+	}
+
+	report, err := New("", dstDir).Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if report.ProblemCount() != 0 {
+		t.Errorf("Verify() = %+v, want no problems", report.Providers)
+	}
+}
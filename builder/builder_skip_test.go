@@ -27,7 +27,8 @@ func calculateFileHash(path string) (string, error) {
 }
 
 // TestBuilderSkipsExistingFiles verifies that the builder skips processing files
-// that are already registered in the index and doesn't modify existing output files.
+// that are already registered in the index with identical content, refuses
+// to silently replace them with different content, and republishes them in force mode.
 func TestBuilderSkipsExistingFiles(t *testing.T) {
 	// Create temporary source and destination directories for tests
 	srcDir, err := os.MkdirTemp("", "builder_skip_test_src")
@@ -62,9 +63,10 @@ func TestBuilderSkipsExistingFiles(t *testing.T) {
 	var fileHashes = make(map[string]string)
 
 	// Paths to check
+	zipPath := filepath.Join(dstDir, "skip", "1.0.0", "download", "linux", "amd64", "terraform-provider-skip_v1.0.0_linux_amd64.zip")
 	expectedPaths := []string{
 		filepath.Join(dstDir, "skip", "versions", "index.json"),
-		filepath.Join(dstDir, "skip", "1.0.0", "download", "linux", "amd64", "terraform-provider-skip_v1.0.0_linux_amd64.zip"),
+		zipPath,
 		filepath.Join(dstDir, "skip", "1.0.0", "download", "linux", "amd64", "terraform-provider-skip_v1.0.0_linux_amd64_SHA256SUMS"),
 		filepath.Join(dstDir, "skip", "1.0.0", "download", "linux", "amd64", "terraform-provider-skip_v1.0.0_linux_amd64_SHA256SUMS.sig"),
 		filepath.Join(dstDir, "skip", "1.0.0", "download", "linux", "amd64", "index.json"),
@@ -85,7 +87,31 @@ func TestBuilderSkipsExistingFiles(t *testing.T) {
 		fileHashes[path] = hash
 	}
 
-	// Step 4: Create a new source file with different content but same name
+	// verifyUnchanged verifies that all file hashes remain unchanged
+	verifyUnchanged := func(t *testing.T) {
+		for _, path := range filePaths {
+			newHash, err := calculateFileHash(path)
+			if err != nil {
+				t.Fatalf("Failed to calculate second hash for %s: %v", path, err)
+			}
+
+			originalHash := fileHashes[path]
+			if newHash != originalHash {
+				t.Errorf("File hash changed for %s:\nOriginal: %s\nNew: %s", path, originalHash, newHash)
+			}
+		}
+	}
+
+	// Step 4: Run the builder again with the same content, which is a no-op
+	t.Run("identical content", func(t *testing.T) {
+		err = b.Build()
+		if err != nil {
+			t.Fatalf("Second Build() error = %v", err)
+		}
+		verifyUnchanged(t)
+	})
+
+	// Step 5: Create a new source file with different content but same name
 	// First, remove the original file
 	err = os.Remove(initialProvider)
 	if err != nil {
@@ -93,28 +119,49 @@ func TestBuilderSkipsExistingFiles(t *testing.T) {
 	}
 
 	// Create a new provider file with different content
-	newContent := "modified binary content that should be ignored"
+	newContent := "modified binary content that should be rejected"
 	err = os.WriteFile(initialProvider, []byte(newContent), 0755)
 	if err != nil {
 		t.Fatalf("Failed to create modified test file: %v", err)
 	}
 
-	// Step 5: Run the builder again
-	err = b.Build()
-	if err != nil {
-		t.Fatalf("Second Build() error = %v", err)
-	}
+	// Step 6: Run the builder again, which must fail without modifying anything
+	t.Run("different content", func(t *testing.T) {
+		err = b.Build()
+		if err == nil {
+			t.Fatalf("Build() with different content error = nil, want error")
+		}
+		verifyUnchanged(t)
+	})
 
-	// Step 6: Verify that all file hashes remain unchanged
-	for _, path := range filePaths {
-		newHash, err := calculateFileHash(path)
+	// Step 7: Run the builder in force mode, which republishes the file
+	t.Run("force", func(t *testing.T) {
+		err = New(srcDir, dstDir, WithForce(true)).Build()
 		if err != nil {
-			t.Fatalf("Failed to calculate second hash for %s: %v", path, err)
+			t.Fatalf("Build() in force mode error = %v", err)
 		}
 
-		originalHash := fileHashes[path]
-		if newHash != originalHash {
-			t.Errorf("File hash changed for %s:\nOriginal: %s\nNew: %s", path, originalHash, newHash)
+		newHash, err := calculateFileHash(zipPath)
+		if err != nil {
+			t.Fatalf("Failed to calculate hash for %s: %v", zipPath, err)
 		}
-	}
+		if newHash == fileHashes[zipPath] {
+			t.Errorf("Zip file was not republished: %s", zipPath)
+		}
+
+		// The republished checksums must match the new zip
+		data, err := os.ReadFile(filePaths[2])
+		if err != nil {
+			t.Fatalf("Failed to read SHA256SUMS: %v", err)
+		}
+		expected := newHash + "  terraform-provider-skip_v1.0.0_linux_amd64.zip\n"
+		if string(data) != expected {
+			t.Errorf("SHA256SUMS content = %q, want %q", string(data), expected)
+		}
+
+		// Building again with the same content is a no-op
+		if err := b.Build(); err != nil {
+			t.Fatalf("Build() after force error = %v", err)
+		}
+	})
 }
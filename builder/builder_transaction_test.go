@@ -0,0 +1,133 @@
+package builder
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// snapshotDir returns the contents of the files in the directory, keyed by their relative paths.
+// Directories are recorded with empty contents.
+func snapshotDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	snapshot := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			snapshot[rel] = ""
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		snapshot[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to snapshot %s: %v", dir, err)
+	}
+	return snapshot
+}
+
+// TestBuilderRollsBackOnFailure verifies that DST is left exactly as it was when signing fails
+// after some files are already written, and that the error lists the rolled back changes.
+func TestBuilderRollsBackOnFailure(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_transaction_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_transaction_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	// Publish the first version
+	providerPath := filepath.Join(srcDir, "terraform-provider-tx_v1.0.0_linux_amd64")
+	if err := os.WriteFile(providerPath, []byte("initial content"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := New(srcDir, dstDir).Build(); err != nil {
+		t.Fatalf("First Build() error = %v", err)
+	}
+	before := snapshotDir(t, dstDir)
+
+	// Republish it with different content and add a new version, with signing broken
+	if err := os.WriteFile(providerPath, []byte("changed content"), 0755); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "terraform-provider-tx_v1.1.0_linux_amd64"), []byte("new content"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	t.Setenv("TFREGBUILDER_GPG_KEY", "")
+
+	err = New(srcDir, dstDir, WithForce(true)).Build()
+	if err == nil {
+		t.Fatal("Build() error = nil, want signing error")
+	}
+	if !strings.Contains(err.Error(), "rolled back") {
+		t.Errorf("Build() error = %v, want the rolled back changes", err)
+	}
+	if !strings.Contains(err.Error(), "restored "+filepath.Join(dstDir, "tx", "1.0.0", "download", "linux", "amd64", "terraform-provider-tx_v1.0.0_linux_amd64.zip")) {
+		t.Errorf("Build() error = %v, want the restored zip file", err)
+	}
+
+	if after := snapshotDir(t, dstDir); !reflect.DeepEqual(before, after) {
+		t.Errorf("DST changed after a failed build:\nbefore: %v\nafter:  %v", keys(before), keys(after))
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(dstDir), "."+filepath.Base(dstDir)+".rollback-*")); len(matches) > 0 {
+		t.Errorf("Previous state is left after a failed build: %v", matches)
+	}
+}
+
+// TestBuilderRemovesStaleTransactions verifies that the previous state of DST left by a killed build
+// is removed by the next build.
+func TestBuilderRemovesStaleTransactions(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := filepath.Join(t.TempDir(), "dst")
+	if err := os.WriteFile(filepath.Join(srcDir, "terraform-provider-tx_v1.0.0_linux_amd64"), []byte("content"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	stalePath := filepath.Join(filepath.Dir(dstDir), ".dst.rollback-0123456789abcdef")
+	if err := os.MkdirAll(filepath.Join(stalePath, "tx"), 0755); err != nil {
+		t.Fatalf("Failed to create stale directory: %v", err)
+	}
+
+	var out bytes.Buffer
+	b := New(srcDir, dstDir)
+	b.out = &out
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Errorf("Stale previous state is not removed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Removed "+stalePath+" left by an interrupted build") {
+		t.Errorf("Output = %q, want the removed previous state", out.String())
+	}
+}
+
+// keys returns the keys of the map.
+func keys(m map[string]string) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
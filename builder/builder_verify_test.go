@@ -0,0 +1,104 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBuilderVerify verifies that a built registry passes verification,
+// and that missing files, hash mismatches and broken signatures are reported.
+func TestBuilderVerify(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_verify_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_verify_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	for _, name := range []string{
+		"terraform-provider-verify_v1.0.0_linux_amd64",
+		"terraform-provider-verify_v1.0.0_darwin_arm64",
+		"terraform-provider-verify_v1.1.0_linux_amd64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := New(srcDir, dstDir, WithVersionSHASums(true)).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	report, err := New("", dstDir).Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(report.Providers) != 1 || report.Providers[0].Platforms != 3 || report.ProblemCount() != 0 {
+		t.Fatalf("Verify() of a built registry = %+v, want 3 platforms without problems", report)
+	}
+
+	// Break the registry
+	downloadDir := filepath.Join(dstDir, "verify", "1.0.0", "download")
+	zipPath := filepath.Join(downloadDir, "linux", "amd64", "terraform-provider-verify_1.0.0_linux_amd64.zip")
+	if err := os.WriteFile(zipPath, []byte("tampered"), 0644); err != nil {
+		t.Fatalf("Failed to tamper zip file: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dstDir, "verify", "1.1.0", "download", "terraform-provider-verify_1.1.0_SHA256SUMS.sig")); err != nil {
+		t.Fatalf("Failed to remove signature file: %v", err)
+	}
+
+	report, err = New("", dstDir).Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	var messages []string
+	for _, problem := range report.Providers[0].Problems {
+		messages = append(messages, problem.Version+" "+problem.OS+"/"+problem.Arch+": "+problem.Message)
+	}
+	text := strings.Join(messages, "\n")
+	for _, want := range []struct {
+		target  string
+		message string
+	}{
+		{"1.0.0 linux/amd64: ", "but the download index lists"},
+		{"1.0.0 linux/amd64: ", "but the SHA256SUMS file lists"},
+		{"1.1.0 linux/amd64: ", "does not exist"},
+	} {
+		found := false
+		for _, message := range messages {
+			if strings.HasPrefix(message, want.target) && strings.Contains(message, want.message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Problems do not include %q for %s:\n%s", want.message, want.target, text)
+		}
+	}
+	if len(messages) != 3 {
+		t.Errorf("Problems = %d, want 3:\n%s", len(messages), text)
+	}
+
+	// A tampered SHA256SUMS file breaks the signature
+	shaSumsPath := filepath.Join(downloadDir, "terraform-provider-verify_1.0.0_SHA256SUMS")
+	f, err := os.OpenFile(shaSumsPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open SHA256SUMS file: %v", err)
+	}
+	f.WriteString("0000  terraform-provider-verify_v1.0.0_windows_amd64.zip\n")
+	f.Close()
+	report, err = New("", dstDir).Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	var sb strings.Builder
+	report.WriteText(&sb)
+	if !strings.Contains(sb.String(), "invalid signature") || !strings.Contains(sb.String(), "FAIL verify") {
+		t.Errorf("Report does not include the invalid signature:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderWarnings verifies that provider-level warnings are set and cleared
+// without touching the version entries, and survive later builds.
+func TestBuilderWarnings(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_warnings_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	dstDir, err := os.MkdirTemp("", "builder_warnings_test_dst")
+	if err != nil {
+		t.Fatalf("Failed to create temporary destination directory: %v", err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	writeProvider := func(name string) {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	readIndex := func() *file.VersionsIndex {
+		versionsIndex, err := file.ReadVersionsIndex(filepath.Join(dstDir, "warn", "versions", "index.json"), "warn")
+		if err != nil {
+			t.Fatalf("Failed to read versions index: %v", err)
+		}
+		return versionsIndex
+	}
+
+	writeProvider("terraform-provider-warn_v1.0.0_linux_amd64")
+	if err := New(srcDir, dstDir).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	before := readIndex()
+
+	// Set warnings
+	warnings := []string{"This provider is deprecated.", "Migrate to example/other."}
+	if err := New("", dstDir).SetWarnings("", "warn", warnings); err != nil {
+		t.Fatalf("SetWarnings() error = %v", err)
+	}
+	after := readIndex()
+	if !reflect.DeepEqual(after.Warnings, warnings) {
+		t.Errorf("Warnings = %v, want %v", after.Warnings, warnings)
+	}
+	if !reflect.DeepEqual(after.Versions, before.Versions) {
+		t.Errorf("Versions after setting warnings = %+v, want %+v", after.Versions, before.Versions)
+	}
+	got, err := New("", dstDir).Warnings("", "warn")
+	if err != nil {
+		t.Fatalf("Warnings() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, warnings) {
+		t.Errorf("Warnings() = %v, want %v", got, warnings)
+	}
+
+	// Warnings survive a build publishing a new version
+	writeProvider("terraform-provider-warn_v1.1.0_linux_amd64")
+	if err := New(srcDir, dstDir).Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	after = readIndex()
+	if !reflect.DeepEqual(after.Warnings, warnings) {
+		t.Errorf("Warnings after build = %v, want %v", after.Warnings, warnings)
+	}
+	if after.FindVersion("1.1.0") == nil {
+		t.Errorf("Version 1.1.0 is not published")
+	}
+
+	// Clear warnings
+	if err := New("", dstDir).SetWarnings("", "warn", nil); err != nil {
+		t.Fatalf("SetWarnings() clear error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dstDir, "warn", "versions", "index.json"))
+	if err != nil {
+		t.Fatalf("Failed to read versions index: %v", err)
+	}
+	if after := readIndex(); after.Warnings != nil || len(after.Versions) != 2 {
+		t.Errorf("Versions index after clearing warnings = %s", data)
+	}
+
+	// Unpublished providers are rejected
+	if err := New("", dstDir).SetWarnings("", "missing", warnings); err == nil {
+		t.Errorf("SetWarnings() for an unpublished provider succeeded, want error")
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("Directory of the unpublished provider is created: %v", err)
+	}
+}
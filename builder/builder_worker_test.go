@@ -0,0 +1,196 @@
+package builder
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// TestBuilderWorkers verifies that building with workers produces the same registry
+// and the same output as building sequentially.
+func TestBuilderWorkers(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "builder_worker_test_src")
+	if err != nil {
+		t.Fatalf("Failed to create temporary source directory: %v", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	platforms := [][2]string{
+		{"linux", "amd64"}, {"linux", "arm64"}, {"linux", "386"},
+		{"darwin", "amd64"}, {"darwin", "arm64"}, {"windows", "amd64"},
+	}
+	for _, providerType := range []string{"alpha", "beta", "gamma"} {
+		for _, version := range []string{"1.0.0", "1.1.0"} {
+			for _, platform := range platforms {
+				name := fmt.Sprintf("terraform-provider-%s_v%s_%s_%s", providerType, version, platform[0], platform[1])
+				if platform[0] == "windows" {
+					name += ".exe"
+				}
+				if err := os.WriteFile(filepath.Join(srcDir, name), []byte("mock binary content "+name), 0755); err != nil {
+					t.Fatalf("Failed to create test file %s: %v", name, err)
+				}
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "per-platform checksums"},
+		{name: "per-version checksums", opts: []Option{WithVersionSHASums(true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publishedAt := time.Now()
+			build := func(workers int) (string, string) {
+				dstDir := t.TempDir()
+				b := New(srcDir, dstDir, append([]Option{WithWorkers(workers)}, tt.opts...)...)
+				var out bytes.Buffer
+				b.out = &out
+				b.now = func() time.Time { return publishedAt }
+				if err := b.Build(); err != nil {
+					t.Fatalf("Build() with %d workers error = %v", workers, err)
+				}
+				return dstDir, out.String()
+			}
+
+			sequentialDir, sequentialOut := build(1)
+			parallelDir, parallelOut := build(8)
+
+			if parallelOut != sequentialOut {
+				t.Errorf("Output with workers = %q, want %q", parallelOut, sequentialOut)
+			}
+
+			sequentialFiles := readTree(t, sequentialDir)
+			parallelFiles := readTree(t, parallelDir)
+			if len(parallelFiles) != len(sequentialFiles) {
+				t.Errorf("Number of files with workers = %d, want %d", len(parallelFiles), len(sequentialFiles))
+			}
+			for path, content := range sequentialFiles {
+				if strings.HasSuffix(path, ".sig") {
+					// Signatures contain the time of signing
+					continue
+				}
+				if got, ok := parallelFiles[path]; !ok {
+					t.Errorf("File %s not created with workers", path)
+				} else if got != content {
+					t.Errorf("File %s with workers = %q, want %q", path, got, content)
+				}
+			}
+		})
+	}
+}
+
+// TestBuilderWorkersError verifies that an error of a job is reported when building with workers.
+func TestBuilderWorkersError(t *testing.T) {
+	srcDir := t.TempDir()
+	for _, name := range []string{
+		"terraform-provider-ok_v1.0.0_linux_amd64",
+		"terraform-provider-bad_vnot-a-version_linux_amd64",
+		"terraform-provider-ok_v1.0.0_darwin_arm64",
+	} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("mock binary content "+name), 0755); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	b := New(srcDir, t.TempDir(), WithWorkers(4))
+	b.out = &bytes.Buffer{}
+	err := b.Build()
+	if err == nil || !strings.Contains(err.Error(), "terraform-provider-bad") {
+		t.Errorf("Build() error = %v, want error for terraform-provider-bad", err)
+	}
+}
+
+// TestBuilderWorkersProtocols verifies that the protocols of a registry manifest packaged for one platform
+// apply to all platforms of the version however the platforms are scheduled among workers.
+func TestBuilderWorkersProtocols(t *testing.T) {
+	srcDir := t.TempDir()
+
+	platforms := [][2]string{
+		{"linux", "amd64"}, {"linux", "arm64"}, {"linux", "386"},
+		{"darwin", "amd64"}, {"darwin", "arm64"}, {"windows", "amd64"},
+	}
+	for i, platform := range platforms {
+		if i != len(platforms)/2 {
+			name := fmt.Sprintf("terraform-provider-mixed_v1.0.0_%s_%s", platform[0], platform[1])
+			if err := os.WriteFile(filepath.Join(srcDir, name), []byte("mock binary content "+name), 0755); err != nil {
+				t.Fatalf("Failed to create test file %s: %v", name, err)
+			}
+			continue
+		}
+
+		// Only this platform declares its protocols
+		zipPath := filepath.Join(srcDir, fmt.Sprintf("terraform-provider-mixed_v1.0.0_%s_%s.zip", platform[0], platform[1]))
+		zipFile, err := os.Create(zipPath)
+		if err != nil {
+			t.Fatalf("Failed to create zip file: %v", err)
+		}
+		zipWriter := zip.NewWriter(zipFile)
+		for name, content := range map[string]string{
+			"terraform-provider-mixed_v1.0.0": "mock binary content",
+			file.RegistryManifestFileName:     `{"version": 1, "metadata": {"protocol_versions": ["5.0"]}}`,
+		} {
+			w, err := zipWriter.Create(name)
+			if err != nil {
+				t.Fatalf("Failed to create zip entry: %v", err)
+			}
+			if _, err := w.Write([]byte(content)); err != nil {
+				t.Fatalf("Failed to write zip entry: %v", err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatalf("Failed to close zip writer: %v", err)
+		}
+		zipFile.Close()
+	}
+
+	for _, opts := range [][]Option{nil, {WithVersionSHASums(true)}} {
+		for i := 0; i < 10; i++ {
+			dstDir := t.TempDir()
+			b := New(srcDir, dstDir, append([]Option{WithWorkers(len(platforms))}, opts...)...)
+			b.out = &bytes.Buffer{}
+			if err := b.Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			assertProtocols(t, dstDir, "mixed", "1.0.0", platforms, []string{"5.0"})
+		}
+	}
+}
+
+// readTree reads all files under the directory, keyed by the relative path.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	return files
+}
@@ -0,0 +1,17 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// lockDst acquires the lock of DST, excluding other processes publishing to it,
+// and returns the function releasing it.
+func (b *Builder) lockDst() (func() error, error) {
+	lock, err := file.LockDir(b.dstDir, b.lockTimeout, b.staleLockAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock destination directory: %w", err)
+	}
+	return lock.Unlock, nil
+}
@@ -0,0 +1,124 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// FilesystemMirror describes a filesystem_mirror directory written next to the registry.
+type FilesystemMirror struct {
+	// Dir is the directory of the mirror, where <hostname>/<namespace>/<type> are laid out.
+	Dir string
+	// Hostname is the hostname of the registry the providers are mirrored from.
+	Hostname string
+	// Unpacked selects the unpacked layout (<type>/<version>/<os>_<arch>/) instead of
+	// the packed layout (<type>/terraform-provider-<type>_<version>_<os>_<arch>.zip).
+	Unpacked bool
+}
+
+// validateFilesystemMirror validates the options of the filesystem mirror.
+func (b *Builder) validateFilesystemMirror() error {
+	if b.filesystemMirror == nil {
+		return nil
+	}
+	if b.filesystemMirror.Dir == "" {
+		return fmt.Errorf("filesystem mirror requires the directory of the mirror")
+	}
+	if !hostnameRegex.MatchString(b.filesystemMirror.Hostname) {
+		return fmt.Errorf("invalid filesystem mirror hostname: %q", b.filesystemMirror.Hostname)
+	}
+	return nil
+}
+
+// updateFilesystemMirror writes the published zip file of the provider to the filesystem mirror.
+// Packages already in the mirror with the same content are left as is.
+func (b *Builder) updateFilesystemMirror(info *provider.ProviderInfo, zipPath string) error {
+	mirror := b.filesystemMirror
+	if info.Namespace == "" {
+		return fmt.Errorf("filesystem mirror requires the namespace of %s", info.FullName())
+	}
+	providerDir := filepath.Join(mirror.Dir, mirror.Hostname, info.Namespace, info.Type)
+
+	if !mirror.Unpacked {
+		mirrorZipPath := filepath.Join(providerDir, info.TargetUpstreamZipFileName())
+		if same, err := sameFileContent(zipPath, mirrorZipPath); err != nil || same {
+			return err
+		}
+		if err := file.EnsureDir(providerDir); err != nil {
+			return fmt.Errorf("failed to create filesystem mirror directory %s: %w", providerDir, err)
+		}
+		if err := file.CopyFile(zipPath, mirrorZipPath); err != nil {
+			return fmt.Errorf("failed to copy zip file to filesystem mirror: %w", err)
+		}
+		fmt.Fprintf(b.out, "Updated filesystem mirror of %s version %s for %s/%s\n", info.FullName(), info.Version, info.OS, info.Arch)
+		return nil
+	}
+
+	packageDir := filepath.Join(providerDir, info.Version, info.OS+"_"+info.Arch)
+	h1, err := file.CalculateZipH1Hash(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to calculate h1 hash of %s: %w", zipPath, err)
+	}
+	if _, err := os.Stat(packageDir); err == nil {
+		current, err := file.CalculateDirH1Hash(packageDir)
+		if err != nil {
+			return err
+		}
+		if current == h1 {
+			return nil
+		}
+		if err := os.RemoveAll(packageDir); err != nil {
+			return fmt.Errorf("failed to remove outdated package %s: %w", packageDir, err)
+		}
+	}
+	if err := file.ExtractZip(zipPath, packageDir); err != nil {
+		return fmt.Errorf("failed to extract zip file to filesystem mirror: %w", err)
+	}
+	fmt.Fprintf(b.out, "Updated filesystem mirror of %s version %s for %s/%s\n", info.FullName(), info.Version, info.OS, info.Arch)
+	return nil
+}
+
+// removeFromFilesystemMirror deletes the package of the platform of the provider version from the filesystem mirror.
+func (b *Builder) removeFromFilesystemMirror(info *provider.ProviderInfo) error {
+	mirror := b.filesystemMirror
+	if info.Namespace == "" {
+		return fmt.Errorf("filesystem mirror requires the namespace of %s", info.FullName())
+	}
+	providerDir := filepath.Join(mirror.Dir, mirror.Hostname, info.Namespace, info.Type)
+
+	path := filepath.Join(providerDir, info.TargetUpstreamZipFileName())
+	if mirror.Unpacked {
+		path = filepath.Join(providerDir, info.Version, info.OS+"_"+info.Arch)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove %s from filesystem mirror: %w", path, err)
+	}
+	if err := file.RemoveEmptyDirs(filepath.Dir(path), filepath.Clean(mirror.Dir)); err != nil {
+		return err
+	}
+	fmt.Fprintf(b.out, "Removed %s version %s for %s/%s from filesystem mirror\n", info.FullName(), info.Version, info.OS, info.Arch)
+	return nil
+}
+
+// sameFileContent returns whether the files have the same content. A missing target is reported as different.
+func sameFileContent(srcPath, targetPath string) (bool, error) {
+	if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+		return false, nil
+	}
+	srcHash, err := file.CalculateSHA256(srcPath)
+	if err != nil {
+		return false, err
+	}
+	targetHash, err := file.CalculateSHA256(targetPath)
+	if err != nil {
+		return false, err
+	}
+	return srcHash == targetHash, nil
+}
@@ -0,0 +1,144 @@
+// Package builder provides the main functionality for building a Terraform registry structure.
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/goreleaser"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
+)
+
+// goreleaserDirJobs returns the jobs processing the goreleaser dist directory,
+// or the dist directories found under the directory.
+func (b *Builder) goreleaserDirJobs(dir string) ([]job, error) {
+	if _, err := os.Stat(filepath.Join(dir, goreleaser.MetadataFileName)); err == nil {
+		return b.goreleaserJobs(dir)
+	}
+
+	subDirs, err := readSubDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []job
+	for _, subDir := range subDirs {
+		subJobs, err := b.goreleaserDirJobs(filepath.Join(dir, subDir))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, subJobs...)
+	}
+	if dir == b.srcDir && len(jobs) == 0 {
+		return nil, fmt.Errorf("no goreleaser dist directories found in %s", dir)
+	}
+	return jobs, nil
+}
+
+// goreleaserJobs returns the jobs processing the provider archives listed in a goreleaser dist directory.
+// The provider type, version and platforms are taken from metadata.json and artifacts.json,
+// the archives are verified against the checksums file and protocol versions are read from the manifest.
+// The namespace is determined from the location of the dist directory, as archives may be outside of it.
+func (b *Builder) goreleaserJobs(distDir string) ([]job, error) {
+	dist, err := goreleaser.ReadDist(distDir)
+	if err != nil {
+		return nil, err
+	}
+
+	providerType, err := dist.ProviderType()
+	if err != nil {
+		return nil, err
+	}
+	version, err := dist.ProviderVersion()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := semver.Parse(version); err != nil {
+		return nil, fmt.Errorf("invalid goreleaser version: %w", err)
+	}
+
+	// Read the combined checksums file, published as is in the per-version SHA256SUMS layout
+	sums := make(map[string]string)
+	var checksumsPath, signaturePath string
+	for _, artifact := range dist.FindArtifacts(func(a *goreleaser.Artifact) bool { return a.Type == goreleaser.TypeChecksum }) {
+		checksumsPath = dist.ArtifactPath(artifact)
+		checksums, err := file.ReadSHA256SumsFile(checksumsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read goreleaser checksums %s: %w", artifact.Name, err)
+		}
+		for name, hash := range checksums {
+			sums[name] = hash
+		}
+
+		signaturePath = ""
+		for _, signature := range dist.FindArtifacts(func(a *goreleaser.Artifact) bool {
+			return a.Type == goreleaser.TypeSignature && a.Name == artifact.Name+".sig"
+		}) {
+			signaturePath = dist.ArtifactPath(signature)
+		}
+	}
+
+	// Read the registry manifest
+	var protocols []string
+	var manifestPath string
+	for _, artifact := range dist.FindArtifacts(func(a *goreleaser.Artifact) bool { return a.IsManifest() }) {
+		manifestPath = dist.ArtifactPath(artifact)
+		manifest, err := file.ReadRegistryManifest(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read goreleaser manifest %s: %w", artifact.Name, err)
+		}
+		protocols = manifest.Metadata.ProtocolVersions
+	}
+
+	archives := dist.FindArtifacts(func(a *goreleaser.Artifact) bool { return a.IsZipArchive() })
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("no zip archives found in goreleaser artifacts in %s", distDir)
+	}
+
+	// Process in a stable order
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].Name < archives[j].Name
+	})
+
+	var jobs []job
+	platforms := make(map[string]string)
+	for _, artifact := range archives {
+		if artifact.Goos == "" || artifact.Goarch == "" {
+			return nil, fmt.Errorf("goreleaser archive %s has no platform", artifact.Name)
+		}
+
+		// Variants like GOARM cannot be distinguished in the registry
+		platform := artifact.Goos + "_" + artifact.Goarch
+		if other, ok := platforms[platform]; ok {
+			return nil, fmt.Errorf("goreleaser archives %s and %s are for the same platform %s", other, artifact.Name, platform)
+		}
+		platforms[platform] = artifact.Name
+
+		shasum, ok := sums[artifact.Name]
+		if !ok && len(sums) > 0 {
+			return nil, fmt.Errorf("goreleaser checksums do not contain %s", artifact.Name)
+		}
+
+		jobs = append(jobs, b.providerJob(&providerSource{
+			path: dist.ArtifactPath(artifact),
+			info: &provider.ProviderInfo{
+				Type:    providerType,
+				Version: version,
+				OS:      artifact.Goos,
+				Arch:    artifact.Goarch,
+			},
+			isZip:         true,
+			protocols:     protocols,
+			shasum:        shasum,
+			namespacePath: filepath.Join(distDir, goreleaser.MetadataFileName),
+			manifestPath:  manifestPath,
+			checksumsPath: checksumsPath,
+			signaturePath: signaturePath,
+		}))
+	}
+
+	return jobs, nil
+}
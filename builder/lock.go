@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// LockHashes returns the hashes of all platforms of a published provider version for a dependency lock file.
+// The "h1:" hashes are read from the sidecar files, or calculated for packages published without them,
+// and the "zh:" hashes are read from the SHA256SUMS files. The namespace is ignored unless DST is the site root.
+func (b *Builder) LockHashes(namespace, providerType, version string) ([]string, error) {
+	if err := b.validateLayout(); err != nil {
+		return nil, err
+	}
+
+	info := &provider.ProviderInfo{Type: providerType, Version: version}
+	if b.isSiteRoot() {
+		info.Namespace = namespace
+	}
+
+	versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+	versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions index file: %w", err)
+	}
+	ver := versionsIndex.FindVersion(version)
+	if ver == nil {
+		return nil, fmt.Errorf("%s version %s is not published", info.FullName(), version)
+	}
+
+	seen := map[string]bool{}
+	var hashes []string
+	for _, plat := range ver.Platforms {
+		platInfo := *info
+		platInfo.OS = plat.OS
+		platInfo.Arch = plat.Arch
+
+		zipPath := b.publishedZipPath(&platInfo)
+		h1, err := file.ReadH1HashFile(h1Path(zipPath))
+		if errors.Is(err, os.ErrNotExist) {
+			h1, err = file.CalculateZipH1Hash(zipPath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get h1 hash of %s version %s for %s/%s: %w", info.FullName(), version, plat.OS, plat.Arch, err)
+		}
+
+		shaSumsPath := b.publishedSHASumsPath(&platInfo)
+		sums, err := file.ReadSHA256SumsFile(shaSumsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checksums of %s version %s for %s/%s: %w", info.FullName(), version, plat.OS, plat.Arch, err)
+		}
+		hash, ok := sums[filepath.Base(zipPath)]
+		if !ok {
+			return nil, fmt.Errorf("checksums %s do not contain %s", shaSumsPath, filepath.Base(zipPath))
+		}
+
+		for _, h := range []string{h1, "zh:" + hash} {
+			if !seen[h] {
+				seen[h] = true
+				hashes = append(hashes, h)
+			}
+		}
+	}
+
+	sort.Strings(hashes)
+	return hashes, nil
+}
@@ -0,0 +1,265 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// hostnameRegex matches the hostname of a registry, optionally with a port.
+var hostnameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*(:[0-9]+)?$`)
+
+// mirrorProviderDir returns the directory of the provider in the network mirror tree,
+// DST/<hostname>/<namespace>/<type>.
+func (b *Builder) mirrorProviderDir(info *provider.ProviderInfo) string {
+	return filepath.Join(b.dstDir, b.mirrorHostname, info.Namespace, info.Type)
+}
+
+// validateMirror validates the options of the network mirror.
+func (b *Builder) validateMirror() error {
+	if b.mirrorHostname == "" {
+		if b.mirrorOnly {
+			return fmt.Errorf("network mirror only mode requires the hostname of the network mirror")
+		}
+		return nil
+	}
+	if !hostnameRegex.MatchString(b.mirrorHostname) {
+		return fmt.Errorf("invalid network mirror hostname: %q", b.mirrorHostname)
+	}
+	if b.isSiteRoot() && !b.mirrorOnly {
+		for _, servicePath := range []string{b.providersPath, b.modulesPath} {
+			if strings.Split(strings.Trim(servicePath, "/"), "/")[0] == b.mirrorHostname {
+				return fmt.Errorf("network mirror hostname conflicts with the registry path %s", servicePath)
+			}
+		}
+	}
+	return nil
+}
+
+// validateMirrors validates the options of the network mirror and the filesystem mirror.
+func (b *Builder) validateMirrors() error {
+	if err := b.validateMirror(); err != nil {
+		return err
+	}
+	return b.validateFilesystemMirror()
+}
+
+// updateMirror adds the published zip file of the provider to the network mirror tree.
+// The zip file is referred to where it is published, and the hashes are taken from its sidecar files.
+func (b *Builder) updateMirror(info *provider.ProviderInfo, zipPath, shaSumsPath string) error {
+	sums, err := file.ReadSHA256SumsFile(shaSumsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read checksums of %s version %s for %s/%s: %w", info.FullName(), info.Version, info.OS, info.Arch, err)
+	}
+	hash, ok := sums[filepath.Base(zipPath)]
+	if !ok {
+		return fmt.Errorf("checksums %s do not contain %s", shaSumsPath, filepath.Base(zipPath))
+	}
+
+	h1, err := file.ReadH1HashFile(h1Path(zipPath))
+	if err != nil {
+		return err
+	}
+
+	return b.writeMirrorArchive(info, zipPath, h1, hash)
+}
+
+// writeMirrorArchive writes the zip file of the provider into the <version>.json and index.json
+// files of the network mirror tree. h1 and hash are the "h1:" hash and the SHA256 hash of the zip file.
+func (b *Builder) writeMirrorArchive(info *provider.ProviderInfo, zipPath, h1, hash string) error {
+	if info.Namespace == "" {
+		return fmt.Errorf("network mirror requires the namespace of %s", info.FullName())
+	}
+
+	mirrorDir := b.mirrorProviderDir(info)
+	if err := file.EnsureDir(mirrorDir); err != nil {
+		return fmt.Errorf("failed to create network mirror directory %s: %w", mirrorDir, err)
+	}
+
+	url, err := b.fileURL(info, mirrorDir, zipPath)
+	if err != nil {
+		return err
+	}
+	archive := file.MirrorArchive{
+		URL:    url,
+		Hashes: []string{h1, "zh:" + hash},
+	}
+
+	versionPath := filepath.Join(mirrorDir, info.Version+".json")
+	mirrorVersion, err := file.ReadMirrorVersion(versionPath)
+	if err != nil {
+		return err
+	}
+	platform := info.OS + "_" + info.Arch
+	if current, ok := mirrorVersion.Archives[platform]; !ok || !equalMirrorArchive(current, archive) {
+		mirrorVersion.Archives[platform] = archive
+		if err := file.WriteMirrorVersion(versionPath, mirrorVersion); err != nil {
+			return err
+		}
+		fmt.Fprintf(b.out, "Updated network mirror of %s version %s for %s/%s\n", info.FullName(), info.Version, info.OS, info.Arch)
+	}
+
+	indexPath := filepath.Join(mirrorDir, "index.json")
+	mirrorIndex, err := file.ReadMirrorIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	if mirrorIndex.AddVersion(info.Version) {
+		if err := file.WriteMirrorIndex(indexPath, mirrorIndex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeMirrorArchive removes the platform of the provider version from the network mirror tree.
+// The version is removed from index.json when no archives are left.
+func (b *Builder) removeMirrorArchive(info *provider.ProviderInfo) error {
+	if info.Namespace == "" {
+		return fmt.Errorf("network mirror requires the namespace of %s", info.FullName())
+	}
+
+	mirrorDir := b.mirrorProviderDir(info)
+	versionPath := filepath.Join(mirrorDir, info.Version+".json")
+	mirrorVersion, err := file.ReadMirrorVersion(versionPath)
+	if err != nil {
+		return err
+	}
+	platform := info.OS + "_" + info.Arch
+	if _, ok := mirrorVersion.Archives[platform]; !ok {
+		return nil
+	}
+	delete(mirrorVersion.Archives, platform)
+
+	// Zip files published only to the network mirror are placed next to the <version>.json file
+	zipPath := filepath.Join(mirrorDir, info.TargetUpstreamZipFileName())
+	if err := os.RemoveAll(zipPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", zipPath, err)
+	}
+
+	if len(mirrorVersion.Archives) > 0 {
+		if err := file.WriteMirrorVersion(versionPath, mirrorVersion); err != nil {
+			return err
+		}
+		fmt.Fprintf(b.out, "Removed %s version %s for %s/%s from network mirror\n", info.FullName(), info.Version, info.OS, info.Arch)
+		return nil
+	}
+
+	if err := os.RemoveAll(versionPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", versionPath, err)
+	}
+	indexPath := filepath.Join(mirrorDir, "index.json")
+	mirrorIndex, err := file.ReadMirrorIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	if mirrorIndex.RemoveVersion(info.Version) {
+		if len(mirrorIndex.Versions) == 0 {
+			err = os.RemoveAll(indexPath)
+		} else {
+			err = file.WriteMirrorIndex(indexPath, mirrorIndex)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := file.RemoveEmptyDirs(mirrorDir, b.dstDir); err != nil {
+		return err
+	}
+	fmt.Fprintf(b.out, "Removed %s version %s for %s/%s from network mirror\n", info.FullName(), info.Version, info.OS, info.Arch)
+	return nil
+}
+
+// processMirrorProvider publishes a provider package only to the network mirror tree.
+// The zip file is placed next to the <version>.json file with the upstream file name.
+// hash is the SHA256 hash of the zip file to publish, if already calculated.
+func (b *Builder) processMirrorProvider(src *providerSource, hash string) error {
+	info := src.info
+	if info.Namespace == "" {
+		return fmt.Errorf("network mirror requires the namespace of %s", info.FullName())
+	}
+
+	if hash == "" {
+		var err error
+		if hash, err = sourceHash(src); err != nil {
+			return err
+		}
+	}
+
+	mirrorDir := b.mirrorProviderDir(info)
+	zipPath := filepath.Join(mirrorDir, info.TargetUpstreamZipFileName())
+	unlock := b.lock(mirrorDir)
+	mirrorVersion, err := file.ReadMirrorVersion(filepath.Join(mirrorDir, info.Version+".json"))
+	unlock()
+	if err != nil {
+		return err
+	}
+	if current, ok := mirrorVersion.Archives[info.OS+"_"+info.Arch]; ok {
+		if slices.Contains(current.Hashes, "zh:"+hash) {
+			if b.planProvider(PlanSkip, src) {
+				return nil
+			}
+			fmt.Fprintf(b.out, "Skipped %s version %s for %s/%s (already in network mirror)\n", info.FullName(), info.Version, info.OS, info.Arch)
+			if b.filesystemMirror != nil {
+				return b.updateFilesystemMirror(info, zipPath)
+			}
+			return nil
+		}
+		if !b.force {
+			if b.planProvider(PlanConflict, src) {
+				return nil
+			}
+			return fmt.Errorf("%s version %s for %s/%s is already published with different content: %s (use force mode to republish)", info.FullName(), info.Version, info.OS, info.Arch, src.path)
+		}
+		if b.planProvider(PlanRepublish, src) {
+			return nil
+		}
+		fmt.Fprintf(b.out, "Republishing %s version %s for %s/%s (content changed)\n", info.FullName(), info.Version, info.OS, info.Arch)
+	} else {
+		if b.planProvider(PlanAdd, src) {
+			return nil
+		}
+		fmt.Fprintf(b.out, "Adding %s version %s for %s/%s to network mirror\n", info.FullName(), info.Version, info.OS, info.Arch)
+	}
+
+	if err := file.EnsureDir(mirrorDir); err != nil {
+		return fmt.Errorf("failed to create network mirror directory %s: %w", mirrorDir, err)
+	}
+	if src.isZip {
+		if err := file.CopyFile(src.path, zipPath); err != nil {
+			return fmt.Errorf("failed to copy zip file: %w", err)
+		}
+	} else {
+		if err := file.CreateZipFromBinary(src.path, zipPath); err != nil {
+			return fmt.Errorf("failed to create zip from binary: %w", err)
+		}
+	}
+
+	h1, err := file.CalculateZipH1Hash(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to calculate h1 hash of %s: %w", zipPath, err)
+	}
+
+	unlock = b.lock(mirrorDir)
+	err = b.writeMirrorArchive(info, zipPath, h1, hash)
+	unlock()
+	if err != nil {
+		return err
+	}
+	if b.filesystemMirror != nil {
+		return b.updateFilesystemMirror(info, zipPath)
+	}
+	return nil
+}
+
+// equalMirrorArchive returns whether two archives of a network mirror are identical.
+func equalMirrorArchive(a, b file.MirrorArchive) bool {
+	return a.URL == b.URL && slices.Equal(a.Hashes, b.Hashes)
+}
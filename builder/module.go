@@ -0,0 +1,130 @@
+// Package builder provides the main functionality for building a Terraform registry structure.
+package builder
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/module"
+)
+
+// modulesDir returns the directory where module namespaces are laid out.
+func (b *Builder) modulesDir() string {
+	return filepath.Join(b.dstDir, filepath.FromSlash(strings.Trim(b.modulesPath, "/")))
+}
+
+// moduleJob returns the job processing a single module source directory or tarball.
+// Jobs publishing versions of the same module share a lane.
+func (b *Builder) moduleJob(srcPath string) job {
+	lane := "module:" + srcPath
+	if info, err := module.ParseModuleName(srcPath); err == nil {
+		lane = "module:" + info.TargetBasePath()
+	}
+
+	return job{
+		lane: lane,
+		run: func(b *Builder) error {
+			return b.processModule(srcPath)
+		},
+	}
+}
+
+// processModule processes a single module source directory or tarball.
+func (b *Builder) processModule(srcPath string) error {
+	// Parse module information from the name
+	info, err := module.ParseModuleName(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse module name %s: %w", srcPath, err)
+	}
+
+	if b.mirrorOnly {
+		return fmt.Errorf("module %s cannot be published to a network mirror", srcPath)
+	}
+	if !b.isSiteRoot() {
+		return fmt.Errorf("publishing module %s requires DST to be the site root", srcPath)
+	}
+	b.setModulesPublished()
+
+	// First, check if this version already exists in the index
+	versionsIndexPath := filepath.Join(b.modulesDir(), info.TargetVersionsIndexPath())
+	unlock := b.lock(versionsIndexPath)
+	defer unlock()
+	versionsIndex, err := file.ReadModuleVersionsIndex(versionsIndexPath)
+	if err != nil {
+		return fmt.Errorf("failed to read module versions index file: %w", err)
+	}
+
+	targetArchivePath := filepath.Join(b.modulesDir(), info.TargetArchivePath())
+	if versionsIndex.HasVersion(info.Version) {
+		// Compare the new archive with the published one
+		var hash string
+		if info.IsArchive(srcPath) {
+			hash, err = file.CalculateSHA256(srcPath)
+		} else {
+			hash, err = file.CalculateTarGzSHA256FromDir(srcPath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to calculate hash of %s: %w", srcPath, err)
+		}
+		publishedHash, err := file.CalculateSHA256(targetArchivePath)
+		if err != nil {
+			return fmt.Errorf("failed to calculate hash of published module %s version %s: %w", info.FullName(), info.Version, err)
+		}
+
+		if hash == publishedHash {
+			if b.planModule(PlanSkip, info, srcPath) {
+				return nil
+			}
+			fmt.Fprintf(b.out, "Skipped module %s version %s (already in index)\n", info.FullName(), info.Version)
+			return nil
+		}
+
+		if !b.force {
+			if b.planModule(PlanConflict, info, srcPath) {
+				return nil
+			}
+			return fmt.Errorf("module %s version %s is already published with different content: %s (use force mode to republish)", info.FullName(), info.Version, srcPath)
+		}
+
+		if b.planModule(PlanRepublish, info, srcPath) {
+			return nil
+		}
+		fmt.Fprintf(b.out, "Republishing module %s version %s (content changed)\n", info.FullName(), info.Version)
+	} else {
+		if b.planModule(PlanAdd, info, srcPath) {
+			return nil
+		}
+		fmt.Fprintf(b.out, "Adding module %s version %s to index\n", info.FullName(), info.Version)
+	}
+
+	// Process source based on its type
+	if info.IsArchive(srcPath) {
+		// Copy tarball directly
+		if err = file.CopyFile(srcPath, targetArchivePath); err != nil {
+			return fmt.Errorf("failed to copy module archive: %w", err)
+		}
+	} else {
+		// Create tarball from directory
+		if err = file.CreateTarGzFromDir(srcPath, targetArchivePath); err != nil {
+			return fmt.Errorf("failed to create module archive: %w", err)
+		}
+	}
+
+	// Create index.json (download) pointing to the archive by its absolute path
+	location := path.Join(b.modulesPath, filepath.ToSlash(info.TargetArchivePath()))
+	downloadIndexPath := filepath.Join(b.modulesDir(), info.TargetDownloadIndexPath())
+	if err = file.WriteModuleDownloadIndex(downloadIndexPath, location); err != nil {
+		return fmt.Errorf("failed to create module download index file: %w", err)
+	}
+
+	// Add the version to the index only after the download endpoint is available
+	versionsIndex.AddVersion(info.Version)
+	if err = file.WriteModuleVersionsIndex(versionsIndexPath, versionsIndex); err != nil {
+		return fmt.Errorf("failed to write module versions index file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// Package builder provides the main functionality for building a Terraform registry structure.
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// NamespaceConfig maps provider files to registry namespaces.
+type NamespaceConfig struct {
+	// Directories maps sub-directories of SRC (slash separated, relative to SRC) to namespaces.
+	// Files are mapped by the longest matching directory.
+	Directories map[string]string `json:"directories,omitempty"`
+	// Providers maps provider types to namespaces. Takes precedence over Directories.
+	Providers map[string]string `json:"providers,omitempty"`
+}
+
+// LoadNamespaceConfig reads a namespace mapping config file.
+func LoadNamespaceConfig(path string) (*NamespaceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespace config: %w", err)
+	}
+
+	var config NamespaceConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse namespace config: %w", err)
+	}
+
+	for _, namespace := range config.Directories {
+		if err := provider.ValidateNamespace(namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace config: %w", err)
+		}
+	}
+	for _, namespace := range config.Providers {
+		if err := provider.ValidateNamespace(namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace config: %w", err)
+		}
+	}
+
+	return &config, nil
+}
+
+// resolveNamespace determines the namespace of a provider file.
+// Returns an empty string when DST is the namespace directory.
+func (b *Builder) resolveNamespace(filePath string, info *provider.ProviderInfo) (string, error) {
+	if !b.isSiteRoot() {
+		return "", nil
+	}
+
+	relPath, err := filepath.Rel(b.srcDir, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path of %s: %w", filePath, err)
+	}
+	relDir := filepath.ToSlash(filepath.Dir(relPath))
+
+	if b.namespaceConfig != nil {
+		// Provider type mapping
+		if namespace, ok := b.namespaceConfig.Providers[info.Type]; ok {
+			return namespace, nil
+		}
+
+		// Longest matching directory mapping
+		matched := ""
+		namespace := ""
+		for dir, ns := range b.namespaceConfig.Directories {
+			dir = strings.Trim(dir, "/")
+			if relDir != dir && !strings.HasPrefix(relDir, dir+"/") {
+				continue
+			}
+			if namespace == "" || len(dir) > len(matched) {
+				matched = dir
+				namespace = ns
+			}
+		}
+		if namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	// The first level sub-directory of SRC
+	if b.namespaceFromDir && relDir != "." {
+		namespace, _, _ := strings.Cut(relDir, "/")
+		if err := provider.ValidateNamespace(namespace); err != nil {
+			return "", fmt.Errorf("cannot use directory of %s as namespace: %w", filePath, err)
+		}
+		return namespace, nil
+	}
+
+	if b.namespace == "" {
+		return "", fmt.Errorf("cannot determine namespace of %s", filePath)
+	}
+	return b.namespace, nil
+}
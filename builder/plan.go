@@ -0,0 +1,193 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/module"
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
+)
+
+// PlanAction is what a build does with a version/platform of a provider or a version of a module.
+type PlanAction string
+
+// Actions of a plan.
+const (
+	PlanAdd       PlanAction = "add"
+	PlanRepublish PlanAction = "republish"
+	PlanSkip      PlanAction = "skip"
+	PlanConflict  PlanAction = "conflict"
+	PlanRemove    PlanAction = "remove"
+)
+
+// planSymbols are the symbols of the actions in the human-readable form.
+var planSymbols = map[PlanAction]string{
+	PlanAdd:       "+",
+	PlanRepublish: "~",
+	PlanSkip:      "=",
+	PlanConflict:  "!",
+	PlanRemove:    "-",
+}
+
+// PlanEntry is the action on a version/platform of a provider or a version of a module.
+type PlanEntry struct {
+	Kind    string     `json:"kind"` // "provider" or "module"
+	Name    string     `json:"name"`
+	Version string     `json:"version"`
+	OS      string     `json:"os,omitempty"`
+	Arch    string     `json:"arch,omitempty"`
+	Action  PlanAction `json:"action"`
+	Source  string     `json:"source"`
+}
+
+// Plan lists what a build would do, made by a dry run.
+type Plan struct {
+	Entries []PlanEntry `json:"entries"`
+}
+
+// Count returns the number of entries with the action.
+func (p *Plan) Count(action PlanAction) int {
+	n := 0
+	for _, entry := range p.Entries {
+		if entry.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges returns whether the build would add, republish or remove anything.
+func (p *Plan) HasChanges() bool {
+	return p.Count(PlanAdd) > 0 || p.Count(PlanRepublish) > 0 || p.Count(PlanRemove) > 0
+}
+
+// HasConflicts returns whether the build would fail because of versions published with different content.
+func (p *Plan) HasConflicts() bool {
+	return p.Count(PlanConflict) > 0
+}
+
+// WriteText writes the plan in the human-readable form.
+func (p *Plan) WriteText(w io.Writer) {
+	for _, entry := range p.Entries {
+		target := entry.Version
+		if entry.OS != "" {
+			target += " for " + entry.OS + "/" + entry.Arch
+		}
+		fmt.Fprintf(w, "%s %s %s %s version %s (%s)\n", planSymbols[entry.Action], entry.Action, entry.Kind, entry.Name, target, entry.Source)
+	}
+	fmt.Fprintf(w, "Plan: %d to add, %d to republish, %d to remove, %d to skip, %d conflicts.\n",
+		p.Count(PlanAdd), p.Count(PlanRepublish), p.Count(PlanRemove), p.Count(PlanSkip), p.Count(PlanConflict))
+}
+
+// sort orders the entries so that the plan does not depend on scheduling.
+func (p *Plan) sort() {
+	sort.SliceStable(p.Entries, func(i, j int) bool {
+		a, b := p.Entries[i], p.Entries[j]
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind // providers first
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if c := semver.CompareStrings(a.Version, b.Version); c != 0 {
+			return c < 0
+		}
+		if a.OS != b.OS {
+			return a.OS < b.OS
+		}
+		return a.Arch < b.Arch
+	})
+}
+
+// Plan returns the plan made by the last dry run of Build.
+func (b *Builder) Plan() *Plan {
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+	return &b.state.plan
+}
+
+// finishPlan orders the entries of the plan made by a dry run, and returns the plan.
+func (b *Builder) finishPlan() *Plan {
+	plan := b.Plan()
+	if plan.Entries == nil {
+		plan.Entries = []PlanEntry{}
+	}
+	plan.sort()
+	return plan
+}
+
+// planProvider records the action on the version/platform of the provider in dry-run mode.
+// Returns whether the build is a dry run, in which case nothing is to be written.
+func (b *Builder) planProvider(action PlanAction, src *providerSource) bool {
+	if !b.dryRun {
+		return false
+	}
+	b.addPlanEntry(PlanEntry{
+		Kind:    "provider",
+		Name:    src.info.FullName(),
+		Version: src.info.Version,
+		OS:      src.info.OS,
+		Arch:    src.info.Arch,
+		Action:  action,
+		Source:  src.path,
+	})
+	return true
+}
+
+// planModule records the action on the version of the module in dry-run mode.
+// Returns whether the build is a dry run, in which case nothing is to be written.
+func (b *Builder) planModule(action PlanAction, info *module.ModuleInfo, srcPath string) bool {
+	if !b.dryRun {
+		return false
+	}
+	b.addPlanEntry(PlanEntry{
+		Kind:    "module",
+		Name:    info.FullName(),
+		Version: info.Version,
+		Action:  action,
+		Source:  srcPath,
+	})
+	return true
+}
+
+// addPlanEntry adds the entry to the plan of the build.
+func (b *Builder) addPlanEntry(entry PlanEntry) {
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+	b.state.plan.Entries = append(b.state.plan.Entries, entry)
+}
+
+// plan runs the jobs in dry-run mode, making the plan of the build.
+// The signer is validated when providers are to be signed.
+func (b *Builder) plan() error {
+	jobs, err := b.jobs()
+	if err != nil {
+		return err
+	}
+	if err := b.runJobs(jobs); err != nil {
+		return err
+	}
+
+	// Versions to prune are previewed against the current versions indexes
+	if b.isPruneEnabled() {
+		if err := b.prune(); err != nil {
+			return err
+		}
+	}
+
+	plan := b.finishPlan()
+	if b.mirrorOnly {
+		return nil
+	}
+	for _, entry := range plan.Entries {
+		if entry.Kind == "provider" && (entry.Action == PlanAdd || entry.Action == PlanRepublish) {
+			if _, err := file.ValidateSignerDryRun(b.signer); err != nil {
+				return fmt.Errorf("signing key error: %w", err)
+			}
+			break
+		}
+	}
+	return nil
+}
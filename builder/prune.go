@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
+)
+
+// PruneRules selects the provider versions removed by pruning.
+// A rule with the zero value is disabled and keeps all the versions it applies to.
+// Versions that are not valid semantic versions are never removed.
+type PruneRules struct {
+	// KeepStable is the number of the newest stable versions to keep.
+	KeepStable int
+	// KeepPrereleasesFor is how long prereleases are kept after they are published.
+	// The publish time is the one recorded in the versions index when the version was first published,
+	// or the modification time of the newest zip file of the version published without it.
+	KeepPrereleasesFor time.Duration
+	// Keep lists the constraints of versions always kept. Versions matching any of them are kept.
+	Keep []semver.Constraints
+}
+
+// IsEmpty returns whether no rules are set.
+func (r *PruneRules) IsEmpty() bool {
+	return r.KeepStable == 0 && r.KeepPrereleasesFor == 0 && len(r.Keep) == 0
+}
+
+// keeps returns whether the version is kept by the constraints.
+func (r *PruneRules) keeps(v *semver.Version) bool {
+	for _, constraints := range r.Keep {
+		if constraints.Check(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrune prunes the provider versions with the rules after a build.
+func WithPrune(rules *PruneRules) Option {
+	return func(b *Builder) {
+		b.pruneRules = rules
+	}
+}
+
+// isPruneEnabled returns whether provider versions are pruned after a build.
+func (b *Builder) isPruneEnabled() bool {
+	return b.pruneRules != nil && !b.pruneRules.IsEmpty() && !b.mirrorOnly
+}
+
+// Prune removes the versions of all published providers selected by the rules set with WithPrune.
+// In dry-run mode, the versions are only recorded in the plan.
+func (b *Builder) Prune() error {
+	if err := b.validateLayout(); err != nil {
+		return err
+	}
+	if err := b.validateMirrors(); err != nil {
+		return err
+	}
+	if b.pruneRules == nil || b.pruneRules.IsEmpty() {
+		return fmt.Errorf("no prune rules are specified")
+	}
+
+	b.state = newBuildState()
+	if b.dryRun {
+		if err := b.prune(); err != nil {
+			return err
+		}
+		b.finishPlan()
+		return nil
+	}
+	return b.transaction(b.prune)
+}
+
+// prune removes the versions of all published providers selected by the prune rules.
+func (b *Builder) prune() error {
+	providers, err := b.publishedProviders()
+	if err != nil {
+		return err
+	}
+
+	for _, info := range providers {
+		versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+		versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+		if err != nil {
+			return fmt.Errorf("failed to read versions index file: %w", err)
+		}
+
+		versions, err := b.prunedVersions(info, versionsIndex)
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			verInfo := *info
+			verInfo.Version = version
+			if b.dryRun {
+				b.addPlanEntry(PlanEntry{
+					Kind:    "provider",
+					Name:    info.FullName(),
+					Version: version,
+					Action:  PlanRemove,
+					Source:  filepath.Join(b.registryDir(), verInfo.TargetVersionPath()),
+				})
+				continue
+			}
+
+			platforms := append([]file.Platform{}, versionsIndex.FindVersion(version).Platforms...)
+			if err := b.removePlatforms(&verInfo, versionsIndex, versionsIndexPath, platforms); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// prunedVersions returns the versions of the provider to remove by the prune rules,
+// counting stable versions in descending order of precedence.
+func (b *Builder) prunedVersions(info *provider.ProviderInfo, versionsIndex *file.VersionsIndex) ([]string, error) {
+	rules := b.pruneRules
+	versionsIndex.SortVersions()
+
+	var pruned []string
+	stable := 0
+	for _, ver := range versionsIndex.Versions {
+		v, err := semver.Parse(ver.Version)
+		if err != nil {
+			continue
+		}
+
+		if !v.IsPrerelease() {
+			stable++
+			if rules.KeepStable == 0 || stable <= rules.KeepStable || rules.keeps(v) {
+				continue
+			}
+			pruned = append(pruned, ver.Version)
+			continue
+		}
+
+		if rules.KeepPrereleasesFor == 0 || rules.keeps(v) {
+			continue
+		}
+		verInfo := *info
+		verInfo.Version = ver.Version
+		publishedAt, err := b.publishedAt(&verInfo, &ver)
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(publishedAt) > rules.KeepPrereleasesFor {
+			pruned = append(pruned, ver.Version)
+		}
+	}
+	return pruned, nil
+}
+
+// publishedAt returns the time the version of the provider was published, recorded in the versions index.
+// Versions published before the time was recorded fall back to the modification time of the newest zip file
+// of the platforms.
+func (b *Builder) publishedAt(info *provider.ProviderInfo, ver *file.VersionInfo) (time.Time, error) {
+	if ver.PublishedAt != nil {
+		return *ver.PublishedAt, nil
+	}
+
+	var latest time.Time
+	for _, plat := range ver.Platforms {
+		platInfo := *info
+		platInfo.OS = plat.OS
+		platInfo.Arch = plat.Arch
+
+		stat, err := os.Stat(b.publishedZipPath(&platInfo))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get publish time of %s version %s for %s/%s: %w", info.FullName(), info.Version, plat.OS, plat.Arch, err)
+		}
+		if stat.ModTime().After(latest) {
+			latest = stat.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// publishedProviders returns the providers published in DST, found by their versions index files.
+// The returned ProviderInfo have only Namespace and Type set.
+func (b *Builder) publishedProviders() ([]*provider.ProviderInfo, error) {
+	registryDir := b.registryDir()
+	if _, err := os.Stat(registryDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("registry directory error: %w", err)
+	}
+
+	// Provider directories are <type>, or <namespace>/<type> when DST is the site root
+	depth := 1
+	if b.isSiteRoot() {
+		depth = 2
+	}
+
+	var providers []*provider.ProviderInfo
+	err := filepath.WalkDir(registryDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != "index.json" || filepath.Base(filepath.Dir(path)) != "versions" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(registryDir, filepath.Dir(filepath.Dir(path)))
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != depth {
+			return nil
+		}
+
+		info := &provider.ProviderInfo{Type: parts[len(parts)-1]}
+		if depth == 2 {
+			info.Namespace = parts[0]
+		}
+		providers = append(providers, info)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find published providers: %w", err)
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].FullName() < providers[j].FullName()
+	})
+	return providers, nil
+}
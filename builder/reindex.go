@@ -0,0 +1,307 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// WithReindex makes a build rebuild the versions index of a provider from its download directories
+// when the index is corrupted, or missing while download directories exist, instead of failing
+// or starting from an empty index.
+func WithReindex(enabled bool) Option {
+	return func(b *Builder) {
+		b.reindex = enabled
+	}
+}
+
+// Reindex rebuilds the versions index of a provider from the download index files under
+// <type>/<version>/download/<os>/<arch>, or the ones of all providers when providerType is empty.
+// Differences between the previous and the rebuilt indexes are reported. Warnings are kept
+// unless the previous index cannot be read.
+// The namespace is ignored unless DST is the site root, where it defaults to the one set with WithNamespace.
+func (b *Builder) Reindex(namespace, providerType string) error {
+	if err := b.validateLayout(); err != nil {
+		return err
+	}
+
+	var providers []*provider.ProviderInfo
+	if providerType != "" {
+		info, err := b.providerInfo(namespace, providerType)
+		if err != nil {
+			return err
+		}
+		providers = append(providers, info)
+	}
+
+	return b.transaction(func() error {
+		if providerType == "" {
+			var err error
+			if providers, err = b.indexedProviders(); err != nil {
+				return err
+			}
+		}
+
+		for _, info := range providers {
+			versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+			oldIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+			if err != nil {
+				fmt.Fprintf(b.out, "Warning: %s: %v\n", versionsIndexPath, err)
+				oldIndex = nil
+			}
+
+			versionsIndex, err := b.rebuildVersionsIndex(info, oldIndex)
+			if err != nil {
+				return err
+			}
+			if len(versionsIndex.Versions) == 0 {
+				if providerType != "" {
+					return fmt.Errorf("no download directories of %s are found", info.FullName())
+				}
+				continue
+			}
+			if reflect.DeepEqual(oldIndex, versionsIndex) {
+				fmt.Fprintf(b.out, "Versions index of %s is up to date\n", info.FullName())
+				continue
+			}
+			if err := file.WriteVersionsIndex(versionsIndexPath, versionsIndex); err != nil {
+				return fmt.Errorf("failed to write versions index file: %w", err)
+			}
+			fmt.Fprintf(b.out, "Reindexed %s: %d versions\n", info.FullName(), len(versionsIndex.Versions))
+		}
+		return nil
+	})
+}
+
+// indexedProviders returns the providers with a versions index or download directories in DST.
+// The returned ProviderInfo have only Namespace and Type set.
+func (b *Builder) indexedProviders() ([]*provider.ProviderInfo, error) {
+	providers, err := b.publishedProviders()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for _, info := range providers {
+		found[info.FullName()] = true
+	}
+
+	// Provider directories are <type>, or <namespace>/<type> when DST is the site root
+	var candidates []*provider.ProviderInfo
+	dirs, err := readSubDirs(b.registryDir())
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !b.isSiteRoot() {
+			candidates = append(candidates, &provider.ProviderInfo{Type: dir})
+			continue
+		}
+		types, err := readSubDirs(filepath.Join(b.registryDir(), dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, providerType := range types {
+			candidates = append(candidates, &provider.ProviderInfo{Namespace: dir, Type: providerType})
+		}
+	}
+
+	for _, info := range candidates {
+		if found[info.FullName()] {
+			continue
+		}
+		platforms, err := b.downloadIndexPaths(info)
+		if err != nil {
+			return nil, err
+		}
+		if len(platforms) > 0 {
+			providers = append(providers, info)
+		}
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].FullName() < providers[j].FullName()
+	})
+	return providers, nil
+}
+
+// readVersionsIndex reads the versions index of the provider. With WithReindex, the index is rebuilt
+// from the download directories when it is corrupted, or missing while download directories exist.
+// The rebuilt index is written unless in dry-run mode.
+func (b *Builder) readVersionsIndex(info *provider.ProviderInfo, versionsIndexPath string) (*file.VersionsIndex, error) {
+	versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+	if !b.reindex {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read versions index file: %w", err)
+		}
+		return versionsIndex, nil
+	}
+
+	if err != nil {
+		fmt.Fprintf(b.out, "Warning: rebuilding corrupted versions index of %s: %v\n", info.FullName(), err)
+	} else if _, statErr := os.Stat(versionsIndexPath); statErr == nil {
+		return versionsIndex, nil
+	}
+
+	rebuilt, err := b.rebuildVersionsIndex(info, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(rebuilt.Versions) == 0 {
+		return rebuilt, nil
+	}
+	if !b.dryRun {
+		if err := file.WriteVersionsIndex(versionsIndexPath, rebuilt); err != nil {
+			return nil, fmt.Errorf("failed to write versions index file: %w", err)
+		}
+	}
+	fmt.Fprintf(b.out, "Recovered versions index of %s: %d versions\n", info.FullName(), len(rebuilt.Versions))
+	return rebuilt, nil
+}
+
+// rebuildVersionsIndex makes the versions index of the provider from its download index files,
+// reporting the differences from the previous index, if any.
+// The protocols of a version are taken from its first platform in OS/architecture order.
+func (b *Builder) rebuildVersionsIndex(info *provider.ProviderInfo, oldIndex *file.VersionsIndex) (*file.VersionsIndex, error) {
+	versionsIndex := &file.VersionsIndex{ID: info.FullName(), Versions: []file.VersionInfo{}}
+	if oldIndex != nil {
+		versionsIndex.Warnings = oldIndex.Warnings
+	}
+
+	platforms, err := b.downloadIndexPaths(info)
+	if err != nil {
+		return nil, err
+	}
+	for _, plat := range platforms {
+		downloadIndex, err := file.ReadDownloadIndex(plat.path)
+		if err != nil {
+			fmt.Fprintf(b.out, "Warning: skipped %s: %v\n", plat.path, err)
+			continue
+		}
+		if ver := versionsIndex.FindVersion(plat.version); ver != nil && !file.EqualProtocols(ver.Protocols, downloadIndex.Protocols) {
+			fmt.Fprintf(b.out, "Warning: %s version %s for %s/%s has protocols %v different from the other platforms %v\n",
+				info.FullName(), plat.version, plat.os, plat.arch, downloadIndex.Protocols, ver.Protocols)
+		}
+		versionsIndex.AddVersion(plat.version, plat.os, plat.arch, downloadIndex.Protocols)
+	}
+
+	if oldIndex != nil {
+		// The publish times are not recorded in the download directories
+		for i := range versionsIndex.Versions {
+			if oldVer := oldIndex.FindVersion(versionsIndex.Versions[i].Version); oldVer != nil {
+				versionsIndex.Versions[i].PublishedAt = oldVer.PublishedAt
+			}
+		}
+		for _, drift := range versionsIndexDrift(oldIndex, versionsIndex) {
+			fmt.Fprintf(b.out, "Drift in %s: %s\n", info.FullName(), drift)
+		}
+	}
+	return versionsIndex, nil
+}
+
+// downloadIndexPath is a download index file found in the download directories of a provider.
+type downloadIndexPath struct {
+	version string
+	os      string
+	arch    string
+	path    string
+}
+
+// downloadIndexPaths returns the download index files of the provider,
+// ordered by version, OS and architecture.
+func (b *Builder) downloadIndexPaths(info *provider.ProviderInfo) ([]downloadIndexPath, error) {
+	baseDir := filepath.Join(b.registryDir(), info.TargetBasePath())
+	versions, err := readSubDirs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []downloadIndexPath
+	for _, version := range versions {
+		downloadDir := filepath.Join(baseDir, version, "download")
+		osNames, err := readSubDirs(downloadDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, osName := range osNames {
+			arches, err := readSubDirs(filepath.Join(downloadDir, osName))
+			if err != nil {
+				return nil, err
+			}
+			for _, arch := range arches {
+				path := filepath.Join(downloadDir, osName, arch, "index.json")
+				if _, err := os.Stat(path); err != nil {
+					continue
+				}
+				paths = append(paths, downloadIndexPath{version: version, os: osName, arch: arch, path: path})
+			}
+		}
+	}
+	return paths, nil
+}
+
+// readSubDirs returns the names of the non-hidden subdirectories of the directory in lexical order,
+// or nothing if the directory does not exist.
+func readSubDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		// Hidden directories are not part of the registry, e.g., .well-known or backups of a transaction
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// versionsIndexDrift describes the differences of the rebuilt versions index from the previous one.
+func versionsIndexDrift(oldIndex, newIndex *file.VersionsIndex) []string {
+	var drift []string
+	for _, newVer := range newIndex.Versions {
+		oldVer := oldIndex.FindVersion(newVer.Version)
+		if oldVer == nil {
+			drift = append(drift, fmt.Sprintf("version %s was missing", newVer.Version))
+			continue
+		}
+		if !file.EqualProtocols(oldVer.Protocols, newVer.Protocols) {
+			drift = append(drift, fmt.Sprintf("protocols of version %s changed from %v to %v", newVer.Version, oldVer.Protocols, newVer.Protocols))
+		}
+		for _, plat := range newVer.Platforms {
+			if !hasPlatform(oldVer, plat) {
+				drift = append(drift, fmt.Sprintf("version %s for %s/%s was missing", newVer.Version, plat.OS, plat.Arch))
+			}
+		}
+		for _, plat := range oldVer.Platforms {
+			if !hasPlatform(&newVer, plat) {
+				drift = append(drift, fmt.Sprintf("version %s for %s/%s has no download directory", newVer.Version, plat.OS, plat.Arch))
+			}
+		}
+	}
+	for _, oldVer := range oldIndex.Versions {
+		if newIndex.FindVersion(oldVer.Version) == nil {
+			drift = append(drift, fmt.Sprintf("version %s has no download directories", oldVer.Version))
+		}
+	}
+	return drift
+}
+
+// hasPlatform returns whether the version lists the platform.
+func hasPlatform(ver *file.VersionInfo, plat file.Platform) bool {
+	for _, p := range ver.Platforms {
+		if p.OS == plat.OS && p.Arch == plat.Arch {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// Remove unpublishes a provider version, or only a platform of it when osName and arch are not empty.
+// The download directories are deleted, the versions index is updated and empty directories are cleaned up.
+// The mirrors set with WithNetworkMirror and WithFilesystemMirror are updated as well.
+// The namespace is ignored unless DST is the site root, where it defaults to the one set with WithNamespace.
+func (b *Builder) Remove(namespace, providerType, version, osName, arch string) error {
+	if err := b.validateLayout(); err != nil {
+		return err
+	}
+	if err := b.validateMirrors(); err != nil {
+		return err
+	}
+
+	info, err := b.providerInfo(namespace, providerType)
+	if err != nil {
+		return err
+	}
+	info.Version = version
+
+	return b.transaction(func() error {
+		versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+		versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+		if err != nil {
+			return fmt.Errorf("failed to read versions index file: %w", err)
+		}
+		ver := versionsIndex.FindVersion(version)
+		if ver == nil {
+			return fmt.Errorf("%s version %s is not published", info.FullName(), version)
+		}
+
+		var platforms []file.Platform
+		if osName == "" {
+			platforms = append(platforms, ver.Platforms...)
+		} else {
+			for _, plat := range ver.Platforms {
+				if plat.OS == osName && plat.Arch == arch {
+					platforms = append(platforms, plat)
+				}
+			}
+			if len(platforms) == 0 {
+				return fmt.Errorf("%s version %s for %s/%s is not published", info.FullName(), version, osName, arch)
+			}
+		}
+
+		return b.removePlatforms(info, versionsIndex, versionsIndexPath, platforms)
+	})
+}
+
+// providerInfo returns the information of the provider with the type, to find it in DST.
+// The namespace is ignored unless DST is the site root, where it defaults to the one set with WithNamespace.
+func (b *Builder) providerInfo(namespace, providerType string) (*provider.ProviderInfo, error) {
+	info := &provider.ProviderInfo{Type: providerType}
+	if b.isSiteRoot() {
+		if namespace == "" {
+			namespace = b.namespace
+		}
+		if namespace == "" {
+			return nil, fmt.Errorf("namespace of %s is required when DST is the site root", providerType)
+		}
+		info.Namespace = namespace
+	}
+	return info, nil
+}
+
+// removePlatforms removes the platforms of the version of the provider from the versions index,
+// and then deletes their files. The SHA256SUMS file shared by the platforms of the version is
+// regenerated for the remaining platforms. The platforms are also removed from the network mirror
+// and the filesystem mirror, if enabled.
+func (b *Builder) removePlatforms(info *provider.ProviderInfo, versionsIndex *file.VersionsIndex, versionsIndexPath string, platforms []file.Platform) error {
+	// Unpublish first, so that clients never see platforms without files
+	for _, plat := range platforms {
+		versionsIndex.RemovePlatform(info.Version, plat.OS, plat.Arch)
+	}
+	if err := file.WriteVersionsIndex(versionsIndexPath, versionsIndex); err != nil {
+		return fmt.Errorf("failed to write versions index file: %w", err)
+	}
+
+	for _, plat := range platforms {
+		platInfo := *info
+		platInfo.OS = plat.OS
+		platInfo.Arch = plat.Arch
+
+		downloadPath := filepath.Join(b.registryDir(), platInfo.TargetDownloadPath())
+		if err := os.RemoveAll(downloadPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", downloadPath, err)
+		}
+	}
+
+	// The shared SHA256SUMS file is updated once for all the platforms
+	if err := b.removeFromVersionSHASums(info, platforms); err != nil {
+		return err
+	}
+
+	for _, plat := range platforms {
+		platInfo := *info
+		platInfo.OS = plat.OS
+		platInfo.Arch = plat.Arch
+
+		downloadPath := filepath.Join(b.registryDir(), platInfo.TargetDownloadPath())
+		if err := file.RemoveEmptyDirs(filepath.Dir(downloadPath), b.registryDir()); err != nil {
+			return err
+		}
+		fmt.Fprintf(b.out, "Removed %s version %s for %s/%s\n", info.FullName(), info.Version, plat.OS, plat.Arch)
+
+		if b.mirrorHostname != "" {
+			if err := b.removeMirrorArchive(&platInfo); err != nil {
+				return err
+			}
+		}
+		if b.filesystemMirror != nil {
+			if err := b.removeFromFilesystemMirror(&platInfo); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeFromVersionSHASums removes the zip files of the platforms from the SHA256SUMS file shared by
+// all platforms of the version, if any. The file is signed again, or deleted together with the
+// registry manifest without signing when no zip files are left.
+func (b *Builder) removeFromVersionSHASums(info *provider.ProviderInfo, platforms []file.Platform) error {
+	shaSumsPath := filepath.Join(b.registryDir(), info.TargetVersionSHASumsPath())
+	sigPath := filepath.Join(b.registryDir(), info.TargetVersionSigPath())
+	if _, err := os.Stat(shaSumsPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	sums, err := file.ReadSHA256SumsFile(shaSumsPath)
+	if err != nil {
+		return err
+	}
+	removed := false
+	for _, plat := range platforms {
+		platInfo := *info
+		platInfo.OS = plat.OS
+		platInfo.Arch = plat.Arch
+		for _, name := range []string{platInfo.TargetUpstreamZipFileName(), platInfo.TargetZipFileName()} {
+			if _, ok := sums[name]; ok {
+				delete(sums, name)
+				removed = true
+			}
+		}
+	}
+	if !removed {
+		return nil
+	}
+
+	zipFiles := 0
+	for name := range sums {
+		if strings.HasSuffix(name, ".zip") {
+			zipFiles++
+		}
+	}
+	if zipFiles == 0 {
+		for _, path := range []string{shaSumsPath, sigPath, filepath.Join(b.registryDir(), info.TargetManifestPath())} {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+
+	if err := file.WriteSHA256Sums(shaSumsPath, sums); err != nil {
+		return fmt.Errorf("failed to create SHA sums file: %w", err)
+	}
+	if err := file.SignFile(b.signer, shaSumsPath, sigPath); err != nil {
+		return fmt.Errorf("failed to create signature file: %w", err)
+	}
+	return nil
+}
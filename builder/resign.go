@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// Resign signs the SHA256SUMS files of all published providers again with the current signer,
+// and replaces the signing keys in their download indexes with its public key.
+// With keepOldKeys, the keys already listed in the download indexes are kept after the current one,
+// so that clients can verify signatures made with either key during a transition.
+// Signatures already made with the current key are kept. Zip files are not changed.
+func (b *Builder) Resign(keepOldKeys bool) error {
+	if err := b.validateLayout(); err != nil {
+		return err
+	}
+	if _, err := file.ValidateSigner(b.signer); err != nil {
+		return fmt.Errorf("signing key error: %w", err)
+	}
+	publicKey, err := b.signer.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	return b.transaction(func() error {
+		providers, err := b.publishedProviders()
+		if err != nil {
+			return err
+		}
+
+		// SHA256SUMS files shared by the platforms of a version are signed once
+		signed := make(map[string]bool)
+		for _, info := range providers {
+			versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+			versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+			if err != nil {
+				return fmt.Errorf("failed to read versions index file: %w", err)
+			}
+
+			for _, ver := range versionsIndex.Versions {
+				for _, plat := range ver.Platforms {
+					platInfo := *info
+					platInfo.Version = ver.Version
+					platInfo.OS = plat.OS
+					platInfo.Arch = plat.Arch
+
+					downloadIndexPath := filepath.Join(b.registryDir(), platInfo.TargetDownloadIndexPath())
+					downloadIndex, err := file.ReadDownloadIndex(downloadIndexPath)
+					if err != nil {
+						return err
+					}
+
+					shaSumsPath := b.publishedSHASumsPath(&platInfo)
+					sigPath := shaSumsPath + ".sig"
+					if !signed[shaSumsPath] {
+						if err := file.VerifySignature(shaSumsPath, sigPath, []file.GPGPublicKey{*publicKey}); err != nil {
+							if err := file.SignFile(b.signer, shaSumsPath, sigPath); err != nil {
+								return fmt.Errorf("failed to create signature file: %w", err)
+							}
+							fmt.Fprintf(b.out, "Signed %s\n", sigPath)
+						}
+						signed[shaSumsPath] = true
+					}
+
+					keys := []file.GPGPublicKey{*publicKey}
+					if keepOldKeys {
+						for _, key := range downloadIndex.SigningKeys.GPGPublicKeys {
+							if key.KeyID != publicKey.KeyID {
+								keys = append(keys, key)
+							}
+						}
+					}
+					changed, err := file.UpdateDownloadIndexSigningKeys(downloadIndexPath, keys)
+					if err != nil {
+						return fmt.Errorf("failed to update download index file: %w", err)
+					}
+					if changed {
+						fmt.Fprintf(b.out, "Updated signing keys of %s version %s for %s/%s\n", info.FullName(), ver.Version, plat.OS, plat.Arch)
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// RewriteURLs rewrites the URLs in the download indexes of all published providers,
+// so that they follow the current download URL settings.
+// Relative URLs are written when no download URL template is configured.
+func (b *Builder) RewriteURLs() error {
+	if err := b.validateLayout(); err != nil {
+		return err
+	}
+	return b.transaction(b.rewriteURLs)
+}
+
+// rewriteURLs rewrites the URLs in the download indexes of all published providers.
+func (b *Builder) rewriteURLs() error {
+	providers, err := b.publishedProviders()
+	if err != nil {
+		return err
+	}
+
+	for _, info := range providers {
+		versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+		versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+		if err != nil {
+			return fmt.Errorf("failed to read versions index file: %w", err)
+		}
+
+		for _, ver := range versionsIndex.Versions {
+			for _, plat := range ver.Platforms {
+				platInfo := *info
+				platInfo.Version = ver.Version
+				platInfo.OS = plat.OS
+				platInfo.Arch = plat.Arch
+
+				downloadIndexPath := filepath.Join(b.registryDir(), platInfo.TargetDownloadIndexPath())
+
+				// Keep referring to the SHA256SUMS file the index currently refers to
+				zipPath := b.publishedZipPath(&platInfo)
+				shaSumsPath := b.publishedSHASumsPath(&platInfo)
+				sigPath := shaSumsPath + ".sig"
+
+				urls, err := b.downloadURLs(&platInfo, zipPath, shaSumsPath, sigPath)
+				if err != nil {
+					return err
+				}
+				changed, err := file.UpdateDownloadIndexURLs(downloadIndexPath, urls)
+				if err != nil {
+					return fmt.Errorf("failed to update download index file: %w", err)
+				}
+				if changed {
+					fmt.Fprintf(b.out, "Rewrote URLs of %s version %s for %s/%s\n", info.FullName(), ver.Version, plat.OS, plat.Arch)
+				}
+			}
+		}
+	}
+
+	return nil
+}
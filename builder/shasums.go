@@ -0,0 +1,211 @@
+// Package builder provides the main functionality for building a Terraform registry structure.
+package builder
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// writeVersionSHASums regenerates and signs the SHA256SUMS file shared by all platforms of the version,
+// listing the zip files and the registry manifest the way upstream releases do.
+// Platforms listed in the versions index but missing in the existing file, e.g., published
+// with per-platform SHA256SUMS files, are added by hashing their zip files.
+// zipPath and hash are the zip file being published and its SHA256 hash.
+// When the source was released with a SHA256SUMS file listing the same files, e.g., by goreleaser,
+// the file is published as is, together with its signature if made with the key of the signer.
+// Returns the paths to the SHA256SUMS file and its signature.
+func (b *Builder) writeVersionSHASums(src *providerSource, versionsIndex *file.VersionsIndex, zipPath, hash string, protocols []string) (string, string, error) {
+	info := src.info
+	shaSumsPath := filepath.Join(b.registryDir(), info.TargetVersionSHASumsPath())
+	sigPath := filepath.Join(b.registryDir(), info.TargetVersionSigPath())
+
+	// Read the existing entries
+	published := make(map[string]string)
+	if _, err := os.Stat(shaSumsPath); err == nil {
+		published, err = file.ReadSHA256SumsFile(shaSumsPath)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	// The zip files of all platforms
+	sums := map[string]string{filepath.Base(zipPath): hash}
+	if ver := versionsIndex.FindVersion(info.Version); ver != nil {
+		for _, plat := range ver.Platforms {
+			platInfo := *info
+			platInfo.OS = plat.OS
+			platInfo.Arch = plat.Arch
+			name := platInfo.TargetUpstreamZipFileName()
+			if _, ok := sums[name]; ok {
+				continue
+			}
+			if publishedHash, ok := published[name]; ok {
+				sums[name] = publishedHash
+				continue
+			}
+
+			platHash, err := file.CalculateSHA256(b.publishedZipPath(&platInfo))
+			if err != nil {
+				return "", "", fmt.Errorf("failed to calculate hash of %s version %s for %s/%s: %w", info.FullName(), info.Version, plat.OS, plat.Arch, err)
+			}
+			sums[name] = platHash
+		}
+	}
+
+	// The registry manifest
+	manifestPath := filepath.Join(b.registryDir(), info.TargetManifestPath())
+	if err := b.writeVersionManifest(src, manifestPath, protocols); err != nil {
+		return "", "", err
+	}
+	manifestHash, err := file.CalculateSHA256(manifestPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to calculate hash of %s: %w", manifestPath, err)
+	}
+	sums[info.TargetManifestFileName()] = manifestHash
+
+	if src.checksumsPath != "" {
+		released, err := file.ReadSHA256SumsFile(src.checksumsPath)
+		if err != nil {
+			return "", "", err
+		}
+		if maps.Equal(released, sums) {
+			if err := b.copyReleasedSHASums(src, shaSumsPath, sigPath); err != nil {
+				return "", "", err
+			}
+			return shaSumsPath, sigPath, nil
+		}
+	}
+
+	if err := file.WriteSHA256Sums(shaSumsPath, sums); err != nil {
+		return "", "", fmt.Errorf("failed to create SHA sums file: %w", err)
+	}
+
+	// Sign SHA256SUMS file again, as its content changed
+	if err := file.SignFile(b.signer, shaSumsPath, sigPath); err != nil {
+		return "", "", fmt.Errorf("failed to create signature file: %w", err)
+	}
+
+	return shaSumsPath, sigPath, nil
+}
+
+// writeVersionManifest publishes the registry manifest of the version listed in the shared SHA256SUMS file.
+// The manifest accompanying the source is copied as is. Otherwise, one declaring the protocols
+// is created unless the version already has one.
+func (b *Builder) writeVersionManifest(src *providerSource, manifestPath string, protocols []string) error {
+	if srcManifestPath := sourceManifestPath(src); srcManifestPath != "" {
+		if same, err := sameFileContent(srcManifestPath, manifestPath); err != nil || same {
+			return err
+		}
+		if err := file.CopyFile(srcManifestPath, manifestPath); err != nil {
+			return fmt.Errorf("failed to copy registry manifest: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := os.Stat(manifestPath); err == nil {
+		return nil
+	}
+	return file.WriteRegistryManifest(manifestPath, protocols)
+}
+
+// copyReleasedSHASums publishes the SHA256SUMS file released with the source.
+// Its signature is published as well if it is made with the key of the signer;
+// otherwise the file is signed again.
+func (b *Builder) copyReleasedSHASums(src *providerSource, shaSumsPath, sigPath string) error {
+	if err := file.CopyFile(src.checksumsPath, shaSumsPath); err != nil {
+		return fmt.Errorf("failed to copy SHA sums file: %w", err)
+	}
+
+	if src.signaturePath != "" {
+		publicKey, err := b.signer.PublicKey()
+		if err != nil {
+			return err
+		}
+		if err := file.VerifySignature(src.checksumsPath, src.signaturePath, []file.GPGPublicKey{*publicKey}); err == nil {
+			if err := file.CopyFile(src.signaturePath, sigPath); err != nil {
+				return fmt.Errorf("failed to copy signature file: %w", err)
+			}
+			return nil
+		}
+		fmt.Fprintf(b.out, "Signing %s again, as %s is not made with GPG key %s\n", filepath.Base(shaSumsPath), src.signaturePath, publicKey.KeyID)
+	}
+
+	if err := file.SignFile(b.signer, shaSumsPath, sigPath); err != nil {
+		return fmt.Errorf("failed to create signature file: %w", err)
+	}
+	return nil
+}
+
+// linkVersionSHASums points the download indexes of all platforms of the version to the shared SHA256SUMS file.
+// Zip files published with other names are renamed the upstream way to match the entries of the file,
+// and the per-platform SHA256SUMS files no longer referred to are deleted.
+func (b *Builder) linkVersionSHASums(info *provider.ProviderInfo, versionsIndex *file.VersionsIndex, shaSumsPath, sigPath string) error {
+	ver := versionsIndex.FindVersion(info.Version)
+	if ver == nil {
+		return nil
+	}
+
+	for _, plat := range ver.Platforms {
+		platInfo := *info
+		platInfo.OS = plat.OS
+		platInfo.Arch = plat.Arch
+
+		downloadIndexPath := filepath.Join(b.registryDir(), platInfo.TargetDownloadIndexPath())
+		zipPath := b.publishedZipPath(&platInfo)
+		upstreamZipPath := filepath.Join(b.registryDir(), platInfo.TargetDownloadPath(), platInfo.TargetUpstreamZipFileName())
+		renamed := zipPath != upstreamZipPath
+		if renamed {
+			if err := renameZip(zipPath, upstreamZipPath); err != nil {
+				return err
+			}
+		}
+
+		urls, err := b.downloadURLs(&platInfo, upstreamZipPath, shaSumsPath, sigPath)
+		if err != nil {
+			return err
+		}
+		changed, err := file.UpdateDownloadIndexFile(downloadIndexPath, filepath.Base(upstreamZipPath), urls)
+		if err != nil {
+			return fmt.Errorf("failed to update download index file: %w", err)
+		}
+		if changed {
+			fmt.Fprintf(b.out, "Pointed %s version %s for %s/%s to %s\n", info.FullName(), info.Version, plat.OS, plat.Arch, filepath.Base(shaSumsPath))
+		}
+
+		for _, path := range []string{
+			filepath.Join(b.registryDir(), platInfo.TargetSHASumsPath()),
+			filepath.Join(b.registryDir(), platInfo.TargetSigPath()),
+		} {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+
+		// The network mirror refers to the zip file by its name
+		if renamed && b.mirrorHostname != "" {
+			if err := b.updateMirror(&platInfo, upstreamZipPath, shaSumsPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// renameZip renames a published zip file together with the file recording its "h1:" hash.
+func renameZip(oldPath, newPath string) error {
+	for _, paths := range [][2]string{{oldPath, newPath}, {h1Path(oldPath), h1Path(newPath)}} {
+		if _, err := os.Stat(paths[0]); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(paths[0], paths[1]); err != nil {
+			return fmt.Errorf("failed to rename %s: %w", paths[0], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// transaction runs fn holding the lock of DST, and rolls back all the changes made by fn on any error.
+func (b *Builder) transaction(fn func() error) (err error) {
+	// Exclude other processes publishing to DST
+	unlock, err := b.lockDst()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	// Discard the previous states left by a build that was killed
+	stale, err := file.RemoveStaleTransactions(b.transactionRoots()...)
+	for _, path := range stale {
+		fmt.Fprintf(b.out, "Removed %s left by an interrupted build\n", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Keep the previous state so that DST is left as it was on any error
+	tx, err := file.BeginTransaction(b.transactionRoots()...)
+	if err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		rolledBack, rollbackErr := tx.Rollback()
+		return rollbackError(err, rolledBack, rollbackErr)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
+
+// transactionRoots returns the directories changed by a build.
+func (b *Builder) transactionRoots() []string {
+	roots := []string{b.dstDir}
+	if b.filesystemMirror != nil {
+		roots = append(roots, b.filesystemMirror.Dir)
+	}
+	return roots
+}
+
+// rollbackError reports the error failing a build along with the rolled back changes.
+func rollbackError(err error, rolledBack []string, rollbackErr error) error {
+	var msg strings.Builder
+	if len(rolledBack) == 0 {
+		msg.WriteString("no changes to roll back")
+	} else {
+		fmt.Fprintf(&msg, "rolled back %d changes:", len(rolledBack))
+		for _, change := range rolledBack {
+			msg.WriteString("\n  " + change)
+		}
+	}
+	if rollbackErr != nil {
+		return fmt.Errorf("%w\n%s\nfailed to roll back: %v", err, msg.String(), rollbackErr)
+	}
+	return fmt.Errorf("%w\n%s", err, msg.String())
+}
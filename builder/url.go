@@ -0,0 +1,124 @@
+package builder
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// placeholderPattern matches placeholders in download URL templates.
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// urlPlaceholders are the placeholders available in download URL templates.
+var urlPlaceholders = map[string]bool{
+	"{namespace}": true,
+	"{type}":      true,
+	"{version}":   true,
+	"{os}":        true,
+	"{arch}":      true,
+	"{filename}":  true,
+	"{path}":      true,
+}
+
+// DownloadURLTemplate builds absolute URLs of the files referenced by download index.json files.
+//
+// The template may contain the placeholders {namespace}, {type}, {version}, {os}, {arch},
+// {filename} and {path}, where {path} is the path of the file relative to DST.
+// A template without placeholders is a base URL and "{path}" is appended to it.
+type DownloadURLTemplate struct {
+	template string
+}
+
+// ParseDownloadURLTemplate parses a download URL template or base URL.
+func ParseDownloadURLTemplate(template string) (*DownloadURLTemplate, error) {
+	placeholders := placeholderPattern.FindAllString(template, -1)
+	for _, placeholder := range placeholders {
+		if !urlPlaceholders[placeholder] {
+			return nil, fmt.Errorf("unknown placeholder %s in download URL template: %s", placeholder, template)
+		}
+	}
+	if len(placeholders) == 0 {
+		if !strings.HasSuffix(template, "/") {
+			template += "/"
+		}
+		template += "{path}"
+	}
+
+	u, err := url.Parse(placeholderPattern.ReplaceAllString(template, "x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid download URL template %s: %w", template, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("download URL template must be an absolute http(s) URL: %s", template)
+	}
+
+	return &DownloadURLTemplate{template: template}, nil
+}
+
+// String returns the template.
+func (t *DownloadURLTemplate) String() string {
+	return t.template
+}
+
+// Expand returns the URL of a file of the provider package.
+// relPath is the path of the file relative to DST.
+func (t *DownloadURLTemplate) Expand(info *provider.ProviderInfo, relPath string) string {
+	segments := strings.Split(filepath.ToSlash(relPath), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	values := map[string]string{
+		"{namespace}": url.PathEscape(info.Namespace),
+		"{type}":      url.PathEscape(info.Type),
+		"{version}":   url.PathEscape(info.Version),
+		"{os}":        url.PathEscape(info.OS),
+		"{arch}":      url.PathEscape(info.Arch),
+		"{filename}":  segments[len(segments)-1],
+		"{path}":      strings.Join(segments, "/"),
+	}
+	return placeholderPattern.ReplaceAllStringFunc(t.template, func(placeholder string) string {
+		return values[placeholder]
+	})
+}
+
+// downloadURLs returns the URLs written into the download index.json file of the provider package.
+func (b *Builder) downloadURLs(info *provider.ProviderInfo, zipPath, shaSumsPath, sigPath string) (*file.DownloadURLs, error) {
+	downloadIndexDir := filepath.Dir(filepath.Join(b.registryDir(), info.TargetDownloadIndexPath()))
+
+	var urls file.DownloadURLs
+	var err error
+	if urls.Download, err = b.fileURL(info, downloadIndexDir, zipPath); err != nil {
+		return nil, err
+	}
+	if urls.Shasums, err = b.fileURL(info, downloadIndexDir, shaSumsPath); err != nil {
+		return nil, err
+	}
+	if urls.ShasumsSignature, err = b.fileURL(info, downloadIndexDir, sigPath); err != nil {
+		return nil, err
+	}
+	return &urls, nil
+}
+
+// fileURL returns the URL of a file of the provider package referred from a file in baseDir.
+// It is relative to baseDir unless a download URL template is configured.
+func (b *Builder) fileURL(info *provider.ProviderInfo, baseDir, targetPath string) (string, error) {
+	relBase := b.dstDir
+	if b.downloadURL == nil {
+		relBase = baseDir
+	}
+
+	relPath, err := filepath.Rel(relBase, targetPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path of %s: %w", targetPath, err)
+	}
+	if b.downloadURL == nil {
+		return filepath.ToSlash(relPath), nil
+	}
+	return b.downloadURL.Expand(info, relPath), nil
+}
@@ -0,0 +1,102 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+func TestParseDownloadURLTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "base URL",
+			template: "https://cdn.example.com/registry",
+			want:     "https://cdn.example.com/registry/{path}",
+		},
+		{
+			name:     "base URL with trailing slash",
+			template: "https://cdn.example.com/registry/",
+			want:     "https://cdn.example.com/registry/{path}",
+		},
+		{
+			name:     "template",
+			template: "https://cdn.example.com/{type}/{version}/{os}_{arch}/{filename}",
+			want:     "https://cdn.example.com/{type}/{version}/{os}_{arch}/{filename}",
+		},
+		{
+			name:     "unknown placeholder",
+			template: "https://cdn.example.com/{name}/{filename}",
+			wantErr:  true,
+		},
+		{
+			name:     "relative URL",
+			template: "/downloads/{filename}",
+			wantErr:  true,
+		},
+		{
+			name:     "unsupported scheme",
+			template: "ftp://cdn.example.com/{filename}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDownloadURLTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDownloadURLTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseDownloadURLTemplate() = %v, want %v", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadURLTemplateExpand(t *testing.T) {
+	info := &provider.ProviderInfo{
+		Namespace: "example",
+		Type:      "test",
+		Version:   "1.0.0+build.1",
+		OS:        "linux",
+		Arch:      "amd64",
+	}
+	relPath := "v1/providers/example/test/1.0.0+build.1/download/linux/amd64/terraform-provider-test_v1.0.0+build.1_linux_amd64.zip"
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "base URL",
+			template: "https://cdn.example.com/registry/",
+			want:     "https://cdn.example.com/registry/" + relPath,
+		},
+		{
+			name:     "all placeholders",
+			template: "https://cdn.example.com/{namespace}/{type}/{version}/{os}/{arch}/{filename}",
+			want:     "https://cdn.example.com/example/test/1.0.0+build.1/linux/amd64/terraform-provider-test_v1.0.0+build.1_linux_amd64.zip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := ParseDownloadURLTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("ParseDownloadURLTemplate() error = %v", err)
+			}
+			if got := template.Expand(info, relPath); got != tt.want {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
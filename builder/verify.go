@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/provider"
+)
+
+// VerifyProblem is a problem found in a published provider by Verify.
+type VerifyProblem struct {
+	Version string `json:"version,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProviderReport is the result of verifying a published provider.
+type ProviderReport struct {
+	Name      string          `json:"name"`
+	Platforms int             `json:"platforms"`
+	Problems  []VerifyProblem `json:"problems"`
+}
+
+// VerifyReport is the result of verifying all published providers.
+type VerifyReport struct {
+	Providers []ProviderReport `json:"providers"`
+}
+
+// ProblemCount returns the number of problems found in all providers.
+func (r *VerifyReport) ProblemCount() int {
+	n := 0
+	for _, p := range r.Providers {
+		n += len(p.Problems)
+	}
+	return n
+}
+
+// WriteText writes the report in the human-readable form.
+func (r *VerifyReport) WriteText(w io.Writer) {
+	for _, p := range r.Providers {
+		if len(p.Problems) == 0 {
+			fmt.Fprintf(w, "OK   %s: %d platforms verified\n", p.Name, p.Platforms)
+			continue
+		}
+		fmt.Fprintf(w, "FAIL %s: %d platforms verified, %d problems\n", p.Name, p.Platforms, len(p.Problems))
+		for _, problem := range p.Problems {
+			target := ""
+			if problem.Version != "" {
+				target = "version " + problem.Version
+				if problem.OS != "" {
+					target += " for " + problem.OS + "/" + problem.Arch
+				}
+				target += ": "
+			}
+			fmt.Fprintf(w, "  - %s%s\n", target, problem.Message)
+		}
+	}
+	fmt.Fprintf(w, "Verified %d providers, %d problems.\n", len(r.Providers), r.ProblemCount())
+}
+
+// Verify checks the files of all published providers without changing anything.
+// For each platform listed in the versions indexes, the download index, the zip file,
+// the SHA256SUMS file and its signature must exist, the hash of the zip file must match
+// both the SHA256SUMS file and the download index, and the signature must be made
+// by one of the signing keys in the download index.
+// Problems are reported in the returned report; errors are returned only when the providers cannot be found.
+func (b *Builder) Verify() (*VerifyReport, error) {
+	if err := b.validateLayout(); err != nil {
+		return nil, err
+	}
+
+	providers, err := b.publishedProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{Providers: []ProviderReport{}}
+	for _, info := range providers {
+		report.Providers = append(report.Providers, b.verifyProvider(info))
+	}
+	return report, nil
+}
+
+// verifyProvider checks the files of all platforms of the published provider.
+func (b *Builder) verifyProvider(info *provider.ProviderInfo) ProviderReport {
+	report := ProviderReport{Name: info.FullName(), Problems: []VerifyProblem{}}
+
+	versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+	versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+	if err != nil {
+		report.Problems = append(report.Problems, VerifyProblem{Message: err.Error()})
+		return report
+	}
+
+	for _, ver := range versionsIndex.Versions {
+		if len(ver.Platforms) == 0 {
+			report.Problems = append(report.Problems, VerifyProblem{Version: ver.Version, Message: "no platforms are listed"})
+		}
+		for _, plat := range ver.Platforms {
+			platInfo := *info
+			platInfo.Version = ver.Version
+			platInfo.OS = plat.OS
+			platInfo.Arch = plat.Arch
+
+			for _, message := range b.verifyPlatform(&platInfo) {
+				report.Problems = append(report.Problems, VerifyProblem{
+					Version: ver.Version,
+					OS:      plat.OS,
+					Arch:    plat.Arch,
+					Message: message,
+				})
+			}
+			report.Platforms++
+		}
+	}
+	return report
+}
+
+// verifyPlatform checks the files of the version/platform of the provider, returning the problems found.
+func (b *Builder) verifyPlatform(info *provider.ProviderInfo) []string {
+	var problems []string
+
+	downloadIndexPath := filepath.Join(b.registryDir(), info.TargetDownloadIndexPath())
+	if _, err := os.Stat(downloadIndexPath); os.IsNotExist(err) {
+		return append(problems, "download index "+downloadIndexPath+" does not exist")
+	}
+	downloadIndex, err := file.ReadDownloadIndex(downloadIndexPath)
+	if err != nil {
+		return append(problems, fmt.Sprintf("%s: %v", downloadIndexPath, err))
+	}
+	if downloadIndex.OS != info.OS || downloadIndex.Arch != info.Arch {
+		problems = append(problems, fmt.Sprintf("download index is for %s/%s", downloadIndex.OS, downloadIndex.Arch))
+	}
+
+	// The per-version SHA256SUMS file lists the zip files named the upstream way
+	shaSumsPath := b.publishedSHASumsPath(info)
+	zipFileName := info.TargetZipFileName()
+	if shaSumsPath == filepath.Join(b.registryDir(), info.TargetVersionSHASumsPath()) {
+		zipFileName = info.TargetUpstreamZipFileName()
+	}
+	if downloadIndex.Filename != zipFileName {
+		problems = append(problems, fmt.Sprintf("download index refers to %s instead of %s", downloadIndex.Filename, zipFileName))
+	}
+
+	// Hash of the zip file
+	zipPath := b.publishedZipPath(info)
+	hash := ""
+	if _, err := os.Stat(zipPath); os.IsNotExist(err) {
+		problems = append(problems, "zip file "+zipPath+" does not exist")
+	} else if hash, err = file.CalculateSHA256(zipPath); err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %v", zipPath, err))
+	} else if hash != downloadIndex.Shasum {
+		problems = append(problems, fmt.Sprintf("SHA256 of the zip file is %s, but the download index lists %s", hash, downloadIndex.Shasum))
+	}
+
+	// SHA256SUMS file the download index refers to
+	if _, err := os.Stat(shaSumsPath); os.IsNotExist(err) {
+		return append(problems, "SHA256SUMS file "+shaSumsPath+" does not exist")
+	}
+	sums, err := file.ReadSHA256SumsFile(shaSumsPath)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %v", shaSumsPath, err))
+	} else if sum, ok := sums[filepath.Base(zipPath)]; !ok {
+		problems = append(problems, "SHA256SUMS file does not list "+filepath.Base(zipPath))
+	} else if hash != "" && sum != hash {
+		problems = append(problems, fmt.Sprintf("SHA256 of the zip file is %s, but the SHA256SUMS file lists %s", hash, sum))
+	}
+
+	// Signature of the SHA256SUMS file
+	sigPath := shaSumsPath + ".sig"
+	if _, err := os.Stat(sigPath); os.IsNotExist(err) {
+		return append(problems, "signature file "+sigPath+" does not exist")
+	}
+	if err := file.VerifySignature(shaSumsPath, sigPath, downloadIndex.SigningKeys.GPGPublicKeys); err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %v", sigPath, err))
+	}
+
+	return problems
+}
@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ikedam/terraform-registry-builder/file"
+)
+
+// Warnings returns the provider-level warnings of a provider published in DST,
+// which Terraform shows to users when they install the provider.
+// The namespace is ignored unless DST is the site root, where it defaults to the one set with WithNamespace.
+func (b *Builder) Warnings(namespace, providerType string) ([]string, error) {
+	if err := b.validateLayout(); err != nil {
+		return nil, err
+	}
+	versionsIndex, _, err := b.publishedVersionsIndex(namespace, providerType)
+	if err != nil {
+		return nil, err
+	}
+	return versionsIndex.Warnings, nil
+}
+
+// SetWarnings replaces the provider-level warnings of a provider published in DST.
+// Empty warnings clear them. Version entries are kept as they are, and later builds keep the warnings.
+// The namespace is ignored unless DST is the site root, where it defaults to the one set with WithNamespace.
+func (b *Builder) SetWarnings(namespace, providerType string, warnings []string) error {
+	if err := b.validateLayout(); err != nil {
+		return err
+	}
+	if len(warnings) == 0 {
+		warnings = nil
+	}
+
+	return b.transaction(func() error {
+		versionsIndex, versionsIndexPath, err := b.publishedVersionsIndex(namespace, providerType)
+		if err != nil {
+			return err
+		}
+		if slices.Equal(versionsIndex.Warnings, warnings) {
+			fmt.Fprintf(b.out, "Warnings of %s are unchanged\n", versionsIndex.ID)
+			return nil
+		}
+
+		versionsIndex.Warnings = warnings
+		if err := file.WriteVersionsIndex(versionsIndexPath, versionsIndex); err != nil {
+			return fmt.Errorf("failed to write versions index file: %w", err)
+		}
+		if warnings == nil {
+			fmt.Fprintf(b.out, "Cleared warnings of %s\n", versionsIndex.ID)
+		} else {
+			fmt.Fprintf(b.out, "Set %d warnings of %s\n", len(warnings), versionsIndex.ID)
+		}
+		return nil
+	})
+}
+
+// publishedVersionsIndex reads the versions index of a provider published in DST, returning it with its path.
+func (b *Builder) publishedVersionsIndex(namespace, providerType string) (*file.VersionsIndex, string, error) {
+	info, err := b.providerInfo(namespace, providerType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	versionsIndexPath := filepath.Join(b.registryDir(), info.TargetVersionsIndexPath())
+	if _, err := os.Stat(versionsIndexPath); os.IsNotExist(err) {
+		return nil, "", fmt.Errorf("%s is not published", info.FullName())
+	}
+	versionsIndex, err := file.ReadVersionsIndex(versionsIndexPath, info.FullName())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read versions index file: %w", err)
+	}
+	return versionsIndex, versionsIndexPath, nil
+}
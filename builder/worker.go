@@ -0,0 +1,144 @@
+package builder
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// job is a unit of work of a build.
+type job struct {
+	// lane identifies the package the job publishes. Jobs of the same lane run
+	// one after another in the order they were found, as they would without workers.
+	lane string
+	run  func(b *Builder) error
+}
+
+// buildState is the state shared by the workers of a build.
+type buildState struct {
+	mu               sync.Mutex
+	locks            map[string]*sync.Mutex
+	modulesPublished bool
+	// plan collects the actions of a dry run.
+	plan Plan
+}
+
+// newBuildState creates the state of a new build.
+func newBuildState() *buildState {
+	return &buildState{
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+// lock acquires the lock for the key, typically the path of an index file shared by jobs,
+// and returns the function releasing it.
+func (b *Builder) lock(key string) func() {
+	b.state.mu.Lock()
+	l, ok := b.state.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		b.state.locks[key] = l
+	}
+	b.state.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// setModulesPublished records that modules are published in this build.
+func (b *Builder) setModulesPublished() {
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+	b.state.modulesPublished = true
+}
+
+// isModulesPublished returns whether modules are published in this build.
+func (b *Builder) isModulesPublished() bool {
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+	return b.state.modulesPublished
+}
+
+// jobResult is the outcome of a job.
+type jobResult struct {
+	out  bytes.Buffer
+	err  error
+	done chan struct{}
+}
+
+// runJobs runs the jobs with the configured number of workers.
+// The output of the jobs is written in the order of the jobs so that it does not depend on scheduling.
+// Once a job fails, jobs not started yet are skipped, and the error of the first failing job is returned.
+func (b *Builder) runJobs(jobs []job) error {
+	workers := b.workers
+	if workers <= 1 {
+		// Without workers, jobs simply run in order
+		for _, j := range jobs {
+			if err := j.run(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Group the jobs by lane, keeping the order they were found in
+	var lanes [][]int
+	laneIndex := make(map[string]int)
+	for i, j := range jobs {
+		n, ok := laneIndex[j.lane]
+		if !ok {
+			n = len(lanes)
+			laneIndex[j.lane] = n
+			lanes = append(lanes, nil)
+		}
+		lanes[n] = append(lanes[n], i)
+	}
+
+	results := make([]*jobResult, len(jobs))
+	for i := range results {
+		results[i] = &jobResult{done: make(chan struct{})}
+	}
+
+	var failed atomic.Bool
+	laneCh := make(chan []int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for lane := range laneCh {
+				for _, i := range lane {
+					result := results[i]
+					if !failed.Load() {
+						// Each job writes to its own buffer
+						jb := *b
+						jb.out = &result.out
+						result.err = jobs[i].run(&jb)
+						if result.err != nil {
+							failed.Store(true)
+						}
+					}
+					close(result.done)
+				}
+			}
+		}()
+	}
+	go func() {
+		for _, lane := range lanes {
+			laneCh <- lane
+		}
+		close(laneCh)
+	}()
+
+	var firstErr error
+	for _, result := range results {
+		<-result.done
+		b.out.Write(result.out.Bytes())
+		if result.err != nil && firstErr == nil {
+			firstErr = result.err
+		}
+	}
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,88 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// AtomicFile is a temporary file in the directory of its destination, renamed into place on Commit.
+// Readers of the destination never see partially written content, even after a crash or a full disk.
+type AtomicFile struct {
+	*os.File
+	path      string
+	committed bool
+}
+
+// CreateAtomic creates a temporary file that becomes path with the permission on Commit.
+// Call Abort, typically deferred, to remove the temporary file unless it is committed.
+func CreateAtomic(path string, perm os.FileMode) (*AtomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	if err := f.Chmod(perm); err != nil && runtime.GOOS != "windows" {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to set mode of temporary file for %s: %w", path, err)
+	}
+	return &AtomicFile{File: f, path: path}, nil
+}
+
+// Commit flushes the content to the disk and renames the temporary file to the destination.
+func (f *AtomicFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", f.path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", f.path, err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to rename temporary file to %s: %w", f.path, err)
+	}
+	f.committed = true
+
+	// Make the rename itself durable
+	return syncDir(filepath.Dir(f.path))
+}
+
+// Abort removes the temporary file unless it is committed.
+func (f *AtomicFile) Abort() {
+	if f.committed {
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// WriteFileAtomic writes data to a file atomically, like os.WriteFile.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := CreateAtomic(path, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Commit()
+}
+
+// syncDir flushes the directory entries to the disk.
+// Directories cannot be synced on Windows, where renames are flushed by the file system.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory %s: %w", dir, err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "atomic-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "index.json")
+	if err := os.WriteFile(path, []byte("old content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("new content"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "new content" {
+		t.Errorf("Content = %q, want %q", data, "new content")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Mode = %v, want 0644", info.Mode().Perm())
+	}
+
+	assertNoTemporaryFiles(t, tmpDir, 1)
+}
+
+func TestAtomicFileAbort(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "atomic-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "test.zip")
+	if err := os.WriteFile(path, []byte("published content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	f, err := CreateAtomic(path, 0644)
+	if err != nil {
+		t.Fatalf("CreateAtomic() error = %v", err)
+	}
+	if _, err := f.Write([]byte("partial")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	f.Abort()
+
+	// The destination keeps the previous content and the temporary file is removed
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "published content" {
+		t.Errorf("Content = %q, want %q", data, "published content")
+	}
+	assertNoTemporaryFiles(t, tmpDir, 1)
+}
+
+// assertNoTemporaryFiles verifies that the directory contains only the expected number of files.
+func assertNoTemporaryFiles(t *testing.T, dir string, want int) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != want {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("Files in directory = %v, want %d files", names, want)
+	}
+}
@@ -0,0 +1,232 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// LockFileName is the name of the advisory lock file at the root of a locked directory.
+const LockFileName = ".terraform-registry-builder.lock"
+
+// lockRetryInterval is the interval of retries while waiting for a lock.
+var lockRetryInterval = 500 * time.Millisecond
+
+// LockHolder describes the process holding a directory lock. It is the content of the lock file.
+type LockHolder struct {
+	Hostname   string    `json:"hostname"`
+	PID        int       `json:"pid"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// String returns the description of the holder for error messages.
+func (h *LockHolder) String() string {
+	return fmt.Sprintf("process %d on %s since %s", h.PID, h.Hostname, h.AcquiredAt.Format(time.RFC3339))
+}
+
+// same returns whether the holders are the same.
+func (h *LockHolder) same(other *LockHolder) bool {
+	return h.Hostname == other.Hostname && h.PID == other.PID && h.AcquiredAt.Equal(other.AcquiredAt)
+}
+
+// DirLock is an advisory lock of a directory shared by processes, possibly on different hosts.
+// The lock file is created exclusively and its modification time is refreshed while the lock is held,
+// so that locks left by crashed processes are detected as stale.
+type DirLock struct {
+	path   string
+	holder LockHolder
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// LockDir acquires the advisory lock of the directory, waiting up to timeout for other holders.
+// A lock is stale, and taken over, when it is not refreshed for staleAfter, or when its holder
+// is a process on this host that no longer exists.
+func LockDir(dir string, timeout, staleAfter time.Duration) (*DirLock, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hostname: %w", err)
+	}
+	l := &DirLock{
+		path: filepath.Join(dir, LockFileName),
+		holder: LockHolder{
+			Hostname: hostname,
+			PID:      os.Getpid(),
+		},
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		holder, err := l.tryLock(staleAfter)
+		if err != nil {
+			return nil, err
+		}
+		if holder == nil {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%s is locked by %s (lock file %s)", dir, holder, l.path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	// Keep the lock fresh while it is held
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.refresh(staleAfter / 4)
+	return l, nil
+}
+
+// tryLock tries to create the lock file once, taking over a stale lock.
+// Returns the current holder if the lock is held by another process.
+func (l *DirLock) tryLock(staleAfter time.Duration) (*LockHolder, error) {
+	l.holder.AcquiredAt = time.Now().UTC().Truncate(time.Second)
+	data, err := json.Marshal(&l.holder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock file: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err == nil {
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(l.path)
+			return nil, fmt.Errorf("failed to write lock file %s: %w", l.path, err)
+		}
+		return nil, nil
+	}
+	if !os.IsExist(err) {
+		return nil, fmt.Errorf("failed to create lock file %s: %w", l.path, err)
+	}
+
+	holder, stale, err := l.readHolder(staleAfter)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Released meanwhile
+			return l.tryLock(staleAfter)
+		}
+		return nil, err
+	}
+	if !stale {
+		return holder, nil
+	}
+	if err := l.breakStale(holder); err != nil {
+		return nil, err
+	}
+	return l.tryLock(staleAfter)
+}
+
+// readHolder reads the lock file and returns its holder and whether the lock is stale.
+func (l *DirLock) readHolder(staleAfter time.Duration) (*LockHolder, bool, error) {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var holder LockHolder
+	if err := json.Unmarshal(data, &holder); err != nil {
+		// The holder may be writing the lock file right now
+		holder = LockHolder{Hostname: "unknown host", AcquiredAt: info.ModTime()}
+	}
+
+	if time.Since(info.ModTime()) > staleAfter {
+		return &holder, true, nil
+	}
+	if holder.Hostname == l.holder.Hostname && holder.PID != 0 && !processExists(holder.PID) {
+		return &holder, true, nil
+	}
+	return &holder, false, nil
+}
+
+// breakStale removes the stale lock file of the holder.
+// The lock file is moved aside first, and put back if another process took over the lock meanwhile.
+func (l *DirLock) breakStale(holder *LockHolder) error {
+	stalePath := fmt.Sprintf("%s.stale-%d-%d", l.path, l.holder.PID, time.Now().UnixNano())
+	if err := os.Rename(l.path, stalePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock file %s: %w", l.path, err)
+	}
+	defer os.Remove(stalePath)
+
+	data, err := os.ReadFile(stalePath)
+	if err != nil {
+		return fmt.Errorf("failed to read stale lock file %s: %w", l.path, err)
+	}
+	var moved LockHolder
+	if json.Unmarshal(data, &moved) == nil && !moved.same(holder) {
+		// Another process took over the lock between the check and the rename
+		if err := os.Link(stalePath, l.path); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to restore lock file %s: %w", l.path, err)
+		}
+	}
+	return nil
+}
+
+// refresh updates the modification time of the lock file until Unlock is called.
+func (l *DirLock) refresh(interval time.Duration) {
+	defer close(l.done)
+	if interval <= 0 {
+		<-l.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// Unlock releases the lock.
+func (l *DirLock) Unlock() error {
+	close(l.stop)
+	<-l.done
+
+	// Do not remove the lock file of another process taking over the lock
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read lock file %s: %w", l.path, err)
+	}
+	var holder LockHolder
+	if err := json.Unmarshal(data, &holder); err != nil || !holder.same(&l.holder) {
+		return fmt.Errorf("lock file %s was taken over by another process", l.path)
+	}
+	if err := os.Remove(l.path); err != nil {
+		return fmt.Errorf("failed to remove lock file %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// processExists returns whether the process exists on this host.
+// Processes are always assumed to exist on Windows, where they cannot be probed with signals.
+func processExists(pid int) bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
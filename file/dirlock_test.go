@@ -0,0 +1,103 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockDir(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dirlock-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	lock, err := LockDir(tmpDir, 0, time.Minute)
+	if err != nil {
+		t.Fatalf("LockDir() error = %v", err)
+	}
+
+	// The lock is held by this process, which is alive
+	_, err = LockDir(tmpDir, 0, time.Minute)
+	if err == nil {
+		t.Fatal("Second LockDir() error = nil, want locked error")
+	}
+	if want := fmt.Sprintf("is locked by process %d", os.Getpid()); !strings.Contains(err.Error(), want) {
+		t.Errorf("Second LockDir() error = %v, want containing %q", err, want)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, LockFileName)); !os.IsNotExist(err) {
+		t.Errorf("Lock file is not removed: %v", err)
+	}
+
+	lock, err = LockDir(tmpDir, 0, time.Minute)
+	if err != nil {
+		t.Fatalf("LockDir() after Unlock() error = %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+}
+
+func TestLockDirStale(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("Failed to get hostname: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		holder LockHolder
+		age    time.Duration
+	}{
+		{
+			name:   "not refreshed",
+			holder: LockHolder{Hostname: "other-host", PID: 1},
+			age:    2 * time.Minute,
+		},
+		{
+			name:   "process exited",
+			holder: LockHolder{Hostname: hostname, PID: 999999999},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir, err := os.MkdirTemp("", "dirlock-test")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(tmpDir)
+
+			lockPath := filepath.Join(tmpDir, LockFileName)
+			data, err := json.Marshal(&tt.holder)
+			if err != nil {
+				t.Fatalf("Failed to marshal holder: %v", err)
+			}
+			if err := os.WriteFile(lockPath, data, 0644); err != nil {
+				t.Fatalf("Failed to create lock file: %v", err)
+			}
+			modTime := time.Now().Add(-tt.age)
+			if err := os.Chtimes(lockPath, modTime, modTime); err != nil {
+				t.Fatalf("Failed to set modification time: %v", err)
+			}
+
+			lock, err := LockDir(tmpDir, 0, time.Minute)
+			if err != nil {
+				t.Fatalf("LockDir() error = %v", err)
+			}
+			if err := lock.Unlock(); err != nil {
+				t.Fatalf("Unlock() error = %v", err)
+			}
+			assertNoTemporaryFiles(t, tmpDir, 0)
+		})
+	}
+}
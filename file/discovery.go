@@ -0,0 +1,67 @@
+// Package file provides utilities for file operations needed by the Terraform registry builder.
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DiscoveryDocumentPath is the path of the service discovery document relative to the site root.
+var DiscoveryDocumentPath = filepath.Join(".well-known", "terraform.json")
+
+// Service identifiers used in the service discovery document.
+const (
+	ProvidersServiceID = "providers.v1"
+	ModulesServiceID   = "modules.v1"
+)
+
+// UpdateDiscoveryDocument writes the service discovery document, merging the given services
+// into the existing document if it exists. Other services in the existing document are preserved.
+// Returns true if the document was created or changed.
+func UpdateDiscoveryDocument(path string, services map[string]string) (bool, error) {
+	document := make(map[string]interface{})
+
+	// Read the existing document
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read service discovery document: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &document); err != nil {
+			return false, fmt.Errorf("failed to parse service discovery document: %w", err)
+		}
+	}
+
+	// Merge services
+	changed := len(data) == 0
+	for id, url := range services {
+		if existing, ok := document[id]; ok && existing == url {
+			continue
+		}
+		document[id] = url
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	// Ensure directory exists
+	if err := EnsureDir(filepath.Dir(path)); err != nil {
+		return false, fmt.Errorf("failed to create directory for service discovery document: %w", err)
+	}
+
+	// Marshal to JSON with indentation (keys are sorted by encoding/json)
+	data, err = json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal service discovery document: %w", err)
+	}
+
+	// Write to file
+	if err := WriteFileAtomic(path, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to write service discovery document: %w", err)
+	}
+
+	return true, nil
+}
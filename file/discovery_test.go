@@ -0,0 +1,83 @@
+package file
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateDiscoveryDocument(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "discovery_test")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, DiscoveryDocumentPath)
+
+	readDocument := func(t *testing.T) map[string]interface{} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read service discovery document: %v", err)
+		}
+		var document map[string]interface{}
+		if err := json.Unmarshal(data, &document); err != nil {
+			t.Fatalf("Failed to parse service discovery document: %v", err)
+		}
+		return document
+	}
+
+	t.Run("create new document", func(t *testing.T) {
+		changed, err := UpdateDiscoveryDocument(path, map[string]string{ProvidersServiceID: "/v1/providers/"})
+		if err != nil {
+			t.Fatalf("UpdateDiscoveryDocument error: %v", err)
+		}
+		if !changed {
+			t.Error("UpdateDiscoveryDocument() = false, want true")
+		}
+
+		document := readDocument(t)
+		if document[ProvidersServiceID] != "/v1/providers/" {
+			t.Errorf("%s = %v, want /v1/providers/", ProvidersServiceID, document[ProvidersServiceID])
+		}
+	})
+
+	t.Run("unchanged document", func(t *testing.T) {
+		changed, err := UpdateDiscoveryDocument(path, map[string]string{ProvidersServiceID: "/v1/providers/"})
+		if err != nil {
+			t.Fatalf("UpdateDiscoveryDocument error: %v", err)
+		}
+		if changed {
+			t.Error("UpdateDiscoveryDocument() = true, want false")
+		}
+	})
+
+	t.Run("merge into existing document", func(t *testing.T) {
+		// Add services managed by others
+		existing := `{"login.v1": {"client": "terraform-cli"}, "providers.v1": "/v1/providers/"}`
+		if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+			t.Fatalf("Failed to write service discovery document: %v", err)
+		}
+
+		_, err := UpdateDiscoveryDocument(path, map[string]string{
+			ProvidersServiceID: "/registry/providers/",
+			ModulesServiceID:   "/registry/modules/",
+		})
+		if err != nil {
+			t.Fatalf("UpdateDiscoveryDocument error: %v", err)
+		}
+
+		document := readDocument(t)
+		if document[ProvidersServiceID] != "/registry/providers/" {
+			t.Errorf("%s = %v, want /registry/providers/", ProvidersServiceID, document[ProvidersServiceID])
+		}
+		if document[ModulesServiceID] != "/registry/modules/" {
+			t.Errorf("%s = %v, want /registry/modules/", ModulesServiceID, document[ModulesServiceID])
+		}
+		if _, ok := document["login.v1"]; !ok {
+			t.Error("login.v1 was not preserved")
+		}
+	})
+}
@@ -3,10 +3,13 @@ package file
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ikedam/terraform-registry-builder/internal/provider"
@@ -17,6 +20,26 @@ func EnsureDir(path string) error {
 	return os.MkdirAll(path, 0755)
 }
 
+// RemoveEmptyDirs removes dir and its parent directories while they are empty, stopping at root.
+// root itself is never removed.
+func RemoveEmptyDirs(dir, root string) error {
+	for ; dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to read directory %s: %w", dir, err)
+		}
+		if len(entries) > 0 {
+			return nil
+		}
+		if err := os.Remove(dir); err != nil {
+			return fmt.Errorf("failed to remove empty directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
 // CopyFile copies a file from src to dst.
 func CopyFile(src, dst string) error {
 	// Open source file
@@ -31,12 +54,12 @@ func CopyFile(src, dst string) error {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	// Create destination file
-	dstFile, err := os.Create(dst)
+	// Create destination file, renamed into place once complete
+	dstFile, err := CreateAtomic(dst, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to create destination file: %w", err)
 	}
-	defer dstFile.Close()
+	defer dstFile.Abort()
 
 	// Copy content
 	_, err = io.Copy(dstFile, srcFile)
@@ -44,7 +67,7 @@ func CopyFile(src, dst string) error {
 		return fmt.Errorf("failed to copy content: %w", err)
 	}
 
-	return nil
+	return dstFile.Commit()
 }
 
 // CreateZipFromBinary creates a zip file containing a single binary with fixed mode and time.
@@ -54,16 +77,34 @@ func CreateZipFromBinary(binaryPath, zipPath string) error {
 		return fmt.Errorf("failed to create directory for zip: %w", err)
 	}
 
-	// Create a new zip file
-	zipFile, err := os.Create(zipPath)
+	// Create a new zip file, renamed into place once complete
+	zipFile, err := CreateAtomic(zipPath, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to create zip file: %w", err)
 	}
-	defer zipFile.Close()
+	defer zipFile.Abort()
+
+	if err := WriteZipFromBinary(binaryPath, zipFile); err != nil {
+		return err
+	}
+	return zipFile.Commit()
+}
 
+// CalculateZipSHA256FromBinary calculates the SHA256 hash of the zip file
+// CreateZipFromBinary would produce for the binary, without writing it.
+func CalculateZipSHA256FromBinary(binaryPath string) (string, error) {
+	hash := sha256.New()
+	if err := WriteZipFromBinary(binaryPath, hash); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// WriteZipFromBinary writes a zip archive containing a single binary with fixed mode and time to w.
+// The output is deterministic for the same binary content.
+func WriteZipFromBinary(binaryPath string, w io.Writer) error {
 	// Create a new zip writer
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	zipWriter := zip.NewWriter(w)
 
 	// Open the binary file
 	binaryFile, err := os.Open(binaryPath)
@@ -100,6 +141,11 @@ func CreateZipFromBinary(binaryPath, zipPath string) error {
 		return fmt.Errorf("failed to write binary to zip: %w", err)
 	}
 
+	// Finish the zip archive
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish zip: %w", err)
+	}
+
 	return nil
 }
 
@@ -111,17 +157,8 @@ func WriteEmptyFile(path string, comment string) error {
 	}
 
 	// Create and write to file
-	file, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	if comment != "" {
-		_, err = file.WriteString(comment)
-		if err != nil {
-			return fmt.Errorf("failed to write comment to file: %w", err)
-		}
+	if err := WriteFileAtomic(path, []byte(comment), 0644); err != nil {
+		return fmt.Errorf("failed to write comment to file: %w", err)
 	}
 
 	return nil
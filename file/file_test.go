@@ -207,3 +207,40 @@ func TestCreateZipFromBinary(t *testing.T) {
 		t.Errorf("Zip content = %q, want %q", string(content), testContent)
 	}
 }
+
+func TestCalculateZipSHA256FromBinary(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "file_test")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	binaryPath := filepath.Join(tmpDir, "terraform-provider-test_v1.0.0_linux_amd64")
+	zipPath := filepath.Join(tmpDir, "output", "test.zip")
+
+	// Create mock binary file
+	err = os.WriteFile(binaryPath, []byte("This is test binary content"), 0755)
+	if err != nil {
+		t.Fatalf("Failed to create binary file: %v", err)
+	}
+
+	err = CreateZipFromBinary(binaryPath, zipPath)
+	if err != nil {
+		t.Fatalf("CreateZipFromBinary() error = %v", err)
+	}
+
+	want, err := CalculateSHA256(zipPath)
+	if err != nil {
+		t.Fatalf("CalculateSHA256() error = %v", err)
+	}
+
+	// The hash must match the zip file without writing it
+	got, err := CalculateZipSHA256FromBinary(binaryPath)
+	if err != nil {
+		t.Fatalf("CalculateZipSHA256FromBinary() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("CalculateZipSHA256FromBinary() = %s, want %s", got, want)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/ProtonMail/gopenpgp/v3/crypto"
@@ -56,24 +58,49 @@ func CalculateSHA256(filePath string) (string, error) {
 	return hashString, nil
 }
 
-// WriteSHA256SumsFile writes the SHA256SUMS file with the hash of the zip file.
-func WriteSHA256SumsFile(zipFilePath, shaSumsPath string) (string, error) {
-	// Calculate SHA256 hash
-	hash, err := CalculateSHA256(zipFilePath)
-	if err != nil {
-		return "", err
-	}
-
+// WriteSHA256Sums writes a SHA256SUMS file listing the hashes keyed by file name.
+// Lines are sorted the way goreleaser does, so the file matches the one of an upstream release.
+func WriteSHA256Sums(shaSumsPath string, sums map[string]string) error {
 	// Format content: hash + two spaces + filename
-	zipFileName := filepath.Base(zipFilePath)
-	content := fmt.Sprintf("%s  %s\n", hash, zipFileName)
+	lines := make([]string, 0, len(sums))
+	for fileName, hash := range sums {
+		lines = append(lines, fmt.Sprintf("%s  %s\n", hash, fileName))
+	}
+	sort.Strings(lines)
+	var content strings.Builder
+	for _, line := range lines {
+		content.WriteString(line)
+	}
 
 	// Write to file
-	if err := os.WriteFile(shaSumsPath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write SHA256SUMS file: %w", err)
+	if err := WriteFileAtomic(shaSumsPath, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write SHA256SUMS file: %w", err)
 	}
 
-	return hash, nil
+	return nil
+}
+
+// ReadSHA256SumsFile reads a SHA256SUMS file and returns the hashes keyed by file name.
+func ReadSHA256SumsFile(shaSumsPath string) (map[string]string, error) {
+	data, err := os.ReadFile(shaSumsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SHA256SUMS file: %w", err)
+	}
+
+	sums := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		// Format: hash + two spaces + filename
+		hash, fileName, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("invalid line in SHA256SUMS file: %q", line)
+		}
+		sums[fileName] = hash
+	}
+
+	return sums, nil
 }
 
 // GetGPGPrivateKey gets the GPG private key from environment variables.
@@ -142,63 +169,52 @@ func GetGPGPrivateKey() (string, string, string, error) {
 	return privateKey, passphrase, keyID, nil
 }
 
-// SignFile signs a file using GPG.
-func SignFile(filePath, signaturePath string) (string, error) {
-	// Get GPG key information
-	privateKeyArmored, passphrase, keyID, err := GetGPGPrivateKey()
+// VerifySignature verifies the detached signature of a file, as written by SignFile,
+// against the public keys listed in a download index.json file.
+func VerifySignature(filePath, signaturePath string, keys []GPGPublicKey) error {
+	fileData, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to read signed file: %w", err)
 	}
-
-	// Read the file to sign
-	fileData, err := os.ReadFile(filePath)
+	signature, err := os.ReadFile(signaturePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file to sign: %w", err)
+		return fmt.Errorf("failed to read signature file: %w", err)
 	}
+	return verifyDetached(fileData, signature, keys)
+}
 
-	// Parse the private key
-	key, err := crypto.NewKeyFromArmored(privateKeyArmored)
+// verifyDetached verifies that the detached signature of the data, either binary or ASCII-armored,
+// is made by one of the keys.
+func verifyDetached(data, signature []byte, keys []GPGPublicKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("no public keys to verify the signature")
+	}
+	keyRing, err := crypto.NewKeyRing(nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse private key: %w", err)
+		return fmt.Errorf("failed to create key ring: %w", err)
 	}
-
-	// Unlock the key with passphrase if provided
-	if passphrase != "" {
-		isLocked, err := key.IsLocked()
+	for _, publicKey := range keys {
+		key, err := crypto.NewKeyFromArmored(publicKey.ASCIIArmor)
 		if err != nil {
-			return "", fmt.Errorf("failed to check if key is locked: %w", err)
+			return fmt.Errorf("failed to parse public key %s: %w", publicKey.KeyID, err)
 		}
-
-		if isLocked {
-			_, err := key.Unlock([]byte(passphrase))
-			if err != nil {
-				return "", fmt.Errorf("failed to unlock private key: %w", err)
-			}
+		if err := keyRing.AddKey(key); err != nil {
+			return fmt.Errorf("failed to add public key %s: %w", publicKey.KeyID, err)
 		}
 	}
 
-	// Initialize PGP
-	pgp := crypto.PGP()
-
-	// Create a signer
-	signer, err := pgp.Sign().SigningKey(key).Detached().New()
+	verifier, err := crypto.PGP().Verify().VerificationKeys(keyRing).New()
 	if err != nil {
-		return "", fmt.Errorf("failed to create signer: %w", err)
+		return fmt.Errorf("failed to create verifier: %w", err)
 	}
-	defer signer.ClearPrivateParams()
-
-	// Sign the data (armor=false for binary output)
-	signature, err := signer.Sign(fileData, crypto.Bytes)
+	result, err := verifier.VerifyDetached(data, signature, crypto.Auto)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign file: %w", err)
+		return fmt.Errorf("failed to verify signature: %w", err)
 	}
-
-	// Write signature to file
-	if err := os.WriteFile(signaturePath, signature, 0644); err != nil {
-		return "", fmt.Errorf("failed to write signature file: %w", err)
+	if err := result.SignatureError(); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
 	}
-
-	return keyID, nil
+	return nil
 }
 
 // GetPublicKey extracts the public key from a private key.
@@ -223,12 +239,18 @@ func GetPublicKey(privateKeyArmored string) (string, error) {
 	return armoredPublicKey, nil
 }
 
-// WriteDownloadIndex creates the download index.json file.
-func WriteDownloadIndex(zipPath, shasumsPath, sigPath, downloadIndexPath string) error {
+// DownloadURLs are the URLs of the files referenced by a download index.json file.
+type DownloadURLs struct {
+	Download         string
+	Shasums          string
+	ShasumsSignature string
+}
+
+// WriteDownloadIndex creates the download index.json file, listing the public key of the signer.
+// shasum is the SHA256 hash of the zip file, as listed in the SHA256SUMS file.
+func WriteDownloadIndex(signer Signer, zipPath, shasum, downloadIndexPath string, urls *DownloadURLs, protocols []string) error {
 	// Extract relevant information from paths
 	zipFileName := filepath.Base(zipPath)
-	shasumsFileName := filepath.Base(shasumsPath)
-	sigFileName := filepath.Base(sigPath)
 
 	// Get provider info from zip file name
 	parts := strings.Split(zipFileName, "_")
@@ -238,44 +260,111 @@ func WriteDownloadIndex(zipPath, shasumsPath, sigPath, downloadIndexPath string)
 	osPart := parts[len(parts)-2]
 	archPart := strings.TrimSuffix(parts[len(parts)-1], ".zip")
 
-	// Calculate SHA256 hash of zip file
-	shasum, err := CalculateSHA256(zipPath)
-	if err != nil {
-		return fmt.Errorf("failed to calculate SHA256 hash: %w", err)
-	}
-
-	// Get GPG key information
-	privateKey, _, keyID, err := GetGPGPrivateKey()
+	// Get the public key of the signer
+	publicKey, err := signer.PublicKey()
 	if err != nil {
 		return err
 	}
 
-	// Get public key
-	publicKey, err := GetPublicKey(privateKey)
-	if err != nil {
-		return fmt.Errorf("failed to get public key: %w", err)
-	}
-
 	// Create download index
 	index := DownloadIndex{
-		Protocols:           []string{"6.0"},
+		Protocols:           append([]string{}, protocols...),
 		OS:                  osPart,
 		Arch:                archPart,
 		Filename:            zipFileName,
-		DownloadURL:         zipFileName,
-		ShasumsURL:          shasumsFileName,
-		ShasumsSignatureURL: sigFileName,
+		DownloadURL:         urls.Download,
+		ShasumsURL:          urls.Shasums,
+		ShasumsSignatureURL: urls.ShasumsSignature,
 		Shasum:              shasum,
 		SigningKeys: SigningKeysObject{
-			GPGPublicKeys: []GPGPublicKey{
-				{
-					KeyID:      keyID,
-					ASCIIArmor: publicKey,
-				},
-			},
+			GPGPublicKeys: []GPGPublicKey{*publicKey},
 		},
 	}
 
+	return writeDownloadIndexFile(downloadIndexPath, &index)
+}
+
+// ReadDownloadIndex reads a download index.json file.
+func ReadDownloadIndex(path string) (*DownloadIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download index: %w", err)
+	}
+
+	var index DownloadIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse download index: %w", err)
+	}
+
+	return &index, nil
+}
+
+// UpdateDownloadIndexProtocols replaces the protocols in an existing download index.json file.
+func UpdateDownloadIndexProtocols(downloadIndexPath string, protocols []string) error {
+	index, err := ReadDownloadIndex(downloadIndexPath)
+	if err != nil {
+		return err
+	}
+
+	index.Protocols = append([]string{}, protocols...)
+	return writeDownloadIndexFile(downloadIndexPath, index)
+}
+
+// UpdateDownloadIndexURLs replaces the URLs in an existing download index.json file.
+// Returns true if the download index was changed.
+func UpdateDownloadIndexURLs(downloadIndexPath string, urls *DownloadURLs) (bool, error) {
+	index, err := ReadDownloadIndex(downloadIndexPath)
+	if err != nil {
+		return false, err
+	}
+
+	if index.DownloadURL == urls.Download && index.ShasumsURL == urls.Shasums && index.ShasumsSignatureURL == urls.ShasumsSignature {
+		return false, nil
+	}
+
+	index.DownloadURL = urls.Download
+	index.ShasumsURL = urls.Shasums
+	index.ShasumsSignatureURL = urls.ShasumsSignature
+	return true, writeDownloadIndexFile(downloadIndexPath, index)
+}
+
+// UpdateDownloadIndexFile replaces the file name and the URLs in an existing download index.json file,
+// as when the zip file is renamed. Returns true if the download index was changed.
+func UpdateDownloadIndexFile(downloadIndexPath, filename string, urls *DownloadURLs) (bool, error) {
+	index, err := ReadDownloadIndex(downloadIndexPath)
+	if err != nil {
+		return false, err
+	}
+
+	if index.Filename == filename && index.DownloadURL == urls.Download && index.ShasumsURL == urls.Shasums && index.ShasumsSignatureURL == urls.ShasumsSignature {
+		return false, nil
+	}
+
+	index.Filename = filename
+	index.DownloadURL = urls.Download
+	index.ShasumsURL = urls.Shasums
+	index.ShasumsSignatureURL = urls.ShasumsSignature
+	return true, writeDownloadIndexFile(downloadIndexPath, index)
+}
+
+// UpdateDownloadIndexSigningKeys replaces the signing keys in an existing download index.json file.
+// Returns true if the download index was changed.
+func UpdateDownloadIndexSigningKeys(downloadIndexPath string, keys []GPGPublicKey) (bool, error) {
+	index, err := ReadDownloadIndex(downloadIndexPath)
+	if err != nil {
+		return false, err
+	}
+
+	if reflect.DeepEqual(index.SigningKeys.GPGPublicKeys, keys) {
+		return false, nil
+	}
+
+	index.SigningKeys.GPGPublicKeys = append([]GPGPublicKey{}, keys...)
+	return true, writeDownloadIndexFile(downloadIndexPath, index)
+}
+
+// writeDownloadIndexFile writes the download index to a file.
+func writeDownloadIndexFile(downloadIndexPath string, index *DownloadIndex) error {
 	// Marshal to JSON with indentation
 	data, err := json.MarshalIndent(index, "", "  ")
 	if err != nil {
@@ -283,7 +372,7 @@ func WriteDownloadIndex(zipPath, shasumsPath, sigPath, downloadIndexPath string)
 	}
 
 	// Write to file
-	if err := os.WriteFile(downloadIndexPath, data, 0644); err != nil {
+	if err := WriteFileAtomic(downloadIndexPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write download index: %w", err)
 	}
 
@@ -96,44 +96,43 @@ func TestGPGFunctions(t *testing.T) {
 		}
 	})
 
-	// Test SHA256SUMS file generation
-	t.Run("WriteSHA256SumsFile", func(t *testing.T) {
-		// Create a temporary directory
+	// Test SHA256SUMS file generation covering multiple files
+	t.Run("WriteSHA256Sums", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "sha-test")
 		if err != nil {
 			t.Fatalf("Failed to create temp dir: %v", err)
 		}
 		defer os.RemoveAll(tmpDir)
 
-		// Create a test file
-		zipPath := tmpDir + "/test.zip"
-		content := []byte("test zip content")
-		if err := os.WriteFile(zipPath, content, 0644); err != nil {
-			t.Fatalf("Failed to create zip file: %v", err)
-		}
-
-		// Generate SHA256SUMS file
 		shaPath := tmpDir + "/SHA256SUMS"
-		hash, err := WriteSHA256SumsFile(zipPath, shaPath)
+		err = WriteSHA256Sums(shaPath, map[string]string{
+			"a_darwin_arm64.zip": "2222",
+			"b_linux_amd64.zip":  "1111",
+			"c_linux_arm64.zip":  "2222",
+		})
 		if err != nil {
-			t.Fatalf("WriteSHA256SumsFile error: %v", err)
-		}
-
-		// Verify file exists
-		if _, err := os.Stat(shaPath); os.IsNotExist(err) {
-			t.Fatalf("SHA256SUMS file not created")
+			t.Fatalf("WriteSHA256Sums error: %v", err)
 		}
 
-		// Verify file content
 		data, err := os.ReadFile(shaPath)
 		if err != nil {
 			t.Fatalf("Failed to read SHA256SUMS file: %v", err)
 		}
 
-		expectedContent := hash + "  test.zip\n"
+		// Lines are sorted as a whole like goreleaser, i.e., by hash and then by file name
+		expectedContent := "1111  b_linux_amd64.zip\n2222  a_darwin_arm64.zip\n2222  c_linux_arm64.zip\n"
 		if string(data) != expectedContent {
 			t.Errorf("SHA256SUMS content = %q, want %q", string(data), expectedContent)
 		}
+
+		// Verify it can be read back
+		sums, err := ReadSHA256SumsFile(shaPath)
+		if err != nil {
+			t.Fatalf("ReadSHA256SumsFile error: %v", err)
+		}
+		if len(sums) != 3 || sums["b_linux_amd64.zip"] != "1111" || sums["c_linux_arm64.zip"] != "2222" {
+			t.Errorf("ReadSHA256SumsFile = %v, want the written hashes", sums)
+		}
 	})
 
 	// Test signing and verifying
@@ -154,12 +153,15 @@ func TestGPGFunctions(t *testing.T) {
 
 		// Sign the file
 		sigPath := tmpDir + "/file.txt.sig"
-		keyID, err := SignFile(filePath, sigPath)
-		if err != nil {
+		if err := SignFile(EnvSigner{}, filePath, sigPath); err != nil {
 			t.Fatalf("SignFile error: %v", err)
 		}
 
 		// Verify key ID matches
+		keyID, err := ValidateSigner(EnvSigner{})
+		if err != nil {
+			t.Fatalf("ValidateSigner error: %v", err)
+		}
 		if keyID != os.Getenv("TFREGBUILDER_GPG_ID") {
 			t.Errorf("Key ID = %s, want %s", keyID, os.Getenv("TFREGBUILDER_GPG_ID"))
 		}
@@ -168,6 +170,24 @@ func TestGPGFunctions(t *testing.T) {
 		if _, err := os.Stat(sigPath); os.IsNotExist(err) {
 			t.Fatalf("Signature file not created")
 		}
+
+		// Verify the signature with the public key
+		publicKey, err := GetPublicKey(os.Getenv("TFREGBUILDER_GPG_KEY"))
+		if err != nil {
+			t.Fatalf("GetPublicKey error: %v", err)
+		}
+		keys := []GPGPublicKey{{KeyID: keyID, ASCIIArmor: publicKey}}
+		if err := VerifySignature(filePath, sigPath, keys); err != nil {
+			t.Errorf("VerifySignature error: %v", err)
+		}
+
+		// Tampered files are rejected
+		if err := os.WriteFile(filePath, []byte("tampered file"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := VerifySignature(filePath, sigPath, keys); err == nil {
+			t.Error("VerifySignature of a tampered file succeeded, want error")
+		}
 	})
 
 	// Test public key extraction
@@ -221,7 +241,12 @@ func TestGPGFunctions(t *testing.T) {
 		}
 
 		// Generate index.json
-		err = WriteDownloadIndex(zipPath, shaPath, sigPath, indexPath)
+		urls := &DownloadURLs{
+			Download:         "terraform-provider-test_v1.0.0_linux_amd64.zip",
+			Shasums:          "terraform-provider-test_v1.0.0_linux_amd64.zip_SHA256SUMS",
+			ShasumsSignature: "terraform-provider-test_v1.0.0_linux_amd64.zip_SHA256SUMS.sig",
+		}
+		err = WriteDownloadIndex(EnvSigner{}, zipPath, "abcdef1234567890", indexPath, urls, []string{"5.0"})
 		if err != nil {
 			t.Fatalf("WriteDownloadIndex error: %v", err)
 		}
@@ -243,8 +268,8 @@ func TestGPGFunctions(t *testing.T) {
 		}
 
 		// Verify basic structure
-		if len(index.Protocols) == 0 {
-			t.Error("No protocols in index")
+		if len(index.Protocols) != 1 || index.Protocols[0] != "5.0" {
+			t.Errorf("Protocols = %v, want [5.0]", index.Protocols)
 		}
 		if index.OS != "linux" {
 			t.Errorf("OS = %s, want 'linux'", index.OS)
@@ -252,9 +277,88 @@ func TestGPGFunctions(t *testing.T) {
 		if index.Arch != "amd64" {
 			t.Errorf("Arch = %s, want 'amd64'", index.Arch)
 		}
+		if index.DownloadURL != "terraform-provider-test_v1.0.0_linux_amd64.zip" {
+			t.Errorf("DownloadURL = %s, want 'terraform-provider-test_v1.0.0_linux_amd64.zip'", index.DownloadURL)
+		}
+		if index.ShasumsURL != "terraform-provider-test_v1.0.0_linux_amd64.zip_SHA256SUMS" {
+			t.Errorf("ShasumsURL = %s, want 'terraform-provider-test_v1.0.0_linux_amd64.zip_SHA256SUMS'", index.ShasumsURL)
+		}
 		if len(index.SigningKeys.GPGPublicKeys) == 0 {
 			t.Error("No signing keys in index")
 		}
+
+		// Replace the URLs with absolute ones
+		absoluteURLs := &DownloadURLs{
+			Download:         "https://cdn.example.com/terraform-provider-test_v1.0.0_linux_amd64.zip",
+			Shasums:          "https://cdn.example.com/terraform-provider-test_v1.0.0_linux_amd64.zip_SHA256SUMS",
+			ShasumsSignature: "https://cdn.example.com/terraform-provider-test_v1.0.0_linux_amd64.zip_SHA256SUMS.sig",
+		}
+		changed, err := UpdateDownloadIndexURLs(indexPath, absoluteURLs)
+		if err != nil {
+			t.Fatalf("UpdateDownloadIndexURLs error: %v", err)
+		}
+		if !changed {
+			t.Error("UpdateDownloadIndexURLs changed = false, want true")
+		}
+		updated, err := ReadDownloadIndex(indexPath)
+		if err != nil {
+			t.Fatalf("ReadDownloadIndex error: %v", err)
+		}
+		if updated.DownloadURL != absoluteURLs.Download || updated.ShasumsSignatureURL != absoluteURLs.ShasumsSignature {
+			t.Errorf("URLs = %s, %s, want %s, %s", updated.DownloadURL, updated.ShasumsSignatureURL, absoluteURLs.Download, absoluteURLs.ShasumsSignature)
+		}
+		if updated.Shasum != index.Shasum {
+			t.Errorf("Shasum = %s, want %s", updated.Shasum, index.Shasum)
+		}
+
+		changed, err = UpdateDownloadIndexURLs(indexPath, absoluteURLs)
+		if err != nil {
+			t.Fatalf("UpdateDownloadIndexURLs error: %v", err)
+		}
+		if changed {
+			t.Error("UpdateDownloadIndexURLs changed = true, want false")
+		}
+
+		// Replace the signing keys
+		keys := []GPGPublicKey{{KeyID: "NEWKEY", ASCIIArmor: "new"}, index.SigningKeys.GPGPublicKeys[0]}
+		changed, err = UpdateDownloadIndexSigningKeys(indexPath, keys)
+		if err != nil {
+			t.Fatalf("UpdateDownloadIndexSigningKeys error: %v", err)
+		}
+		if !changed {
+			t.Error("UpdateDownloadIndexSigningKeys changed = false, want true")
+		}
+		updated, err = ReadDownloadIndex(indexPath)
+		if err != nil {
+			t.Fatalf("ReadDownloadIndex error: %v", err)
+		}
+		if len(updated.SigningKeys.GPGPublicKeys) != 2 || updated.SigningKeys.GPGPublicKeys[0].KeyID != "NEWKEY" {
+			t.Errorf("Signing keys = %+v, want NEWKEY and the previous key", updated.SigningKeys.GPGPublicKeys)
+		}
+		if updated.DownloadURL != absoluteURLs.Download {
+			t.Errorf("DownloadURL = %s, want %s", updated.DownloadURL, absoluteURLs.Download)
+		}
+
+		// Rename the zip file
+		renamedURLs := &DownloadURLs{
+			Download:         "terraform-provider-test_1.0.0_linux_amd64.zip",
+			Shasums:          "../../terraform-provider-test_1.0.0_SHA256SUMS",
+			ShasumsSignature: "../../terraform-provider-test_1.0.0_SHA256SUMS.sig",
+		}
+		changed, err = UpdateDownloadIndexFile(indexPath, "terraform-provider-test_1.0.0_linux_amd64.zip", renamedURLs)
+		if err != nil {
+			t.Fatalf("UpdateDownloadIndexFile error: %v", err)
+		}
+		if !changed {
+			t.Error("UpdateDownloadIndexFile changed = false, want true")
+		}
+		updated, err = ReadDownloadIndex(indexPath)
+		if err != nil {
+			t.Fatalf("ReadDownloadIndex error: %v", err)
+		}
+		if updated.Filename != "terraform-provider-test_1.0.0_linux_amd64.zip" || updated.ShasumsURL != renamedURLs.Shasums {
+			t.Errorf("Filename, ShasumsURL = %s, %s, want %s, %s", updated.Filename, updated.ShasumsURL, "terraform-provider-test_1.0.0_linux_amd64.zip", renamedURLs.Shasums)
+		}
 	})
 }
 
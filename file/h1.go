@@ -0,0 +1,131 @@
+package file
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CalculateZipH1Hash calculates the "h1:" hash of a provider package zip file.
+// This is the hash Terraform records in dependency lock files, computed with the
+// algorithm of golang.org/x/mod/sumdb/dirhash Hash1 over the files in the zip file.
+func CalculateZipH1Hash(zipPath string) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer r.Close()
+
+	files := make(map[string]*zip.File, len(r.File))
+	names := make([]string, 0, len(r.File))
+	for _, f := range r.File {
+		// Directory entries have no content, and unpacked packages have no files for them
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+
+	return calculateH1Hash(names, func(name string) (io.ReadCloser, error) {
+		return files[name].Open()
+	})
+}
+
+// CalculateDirH1Hash calculates the "h1:" hash of an unpacked provider package directory.
+// It equals the hash of the zip file the directory was extracted from.
+func CalculateDirH1Hash(dir string) (string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list files in %s: %w", dir, err)
+	}
+
+	return calculateH1Hash(names, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+}
+
+// calculateH1Hash calculates the "h1:" hash of the files opened with open.
+func calculateH1Hash(names []string, open func(name string) (io.ReadCloser, error)) (string, error) {
+	names = append([]string{}, names...)
+	sort.Strings(names)
+
+	summary := sha256.New()
+	for _, name := range names {
+		if strings.Contains(name, "\n") {
+			return "", fmt.Errorf("file name with newline: %q", name)
+		}
+		hash, err := hashFileContent(name, open)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", hash, name)
+	}
+
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+// WriteH1HashFile calculates the "h1:" hash of the zip file and records it in a sidecar file.
+// Returns the hash.
+func WriteH1HashFile(zipPath, h1Path string) (string, error) {
+	hash, err := CalculateZipH1Hash(zipPath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := WriteFileAtomic(h1Path, []byte(hash+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write h1 hash file: %w", err)
+	}
+
+	return hash, nil
+}
+
+// ReadH1HashFile reads the "h1:" hash recorded in a sidecar file.
+func ReadH1HashFile(h1Path string) (string, error) {
+	data, err := os.ReadFile(h1Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read h1 hash file: %w", err)
+	}
+
+	hash := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(hash, "h1:") {
+		return "", fmt.Errorf("invalid h1 hash file %s", h1Path)
+	}
+	return hash, nil
+}
+
+// hashFileContent calculates the SHA256 hash of the content of a file.
+func hashFileContent(name string, open func(name string) (io.ReadCloser, error)) ([]byte, error) {
+	rc, err := open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, rc); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,84 @@
+package file
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCalculateZipH1Hash(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "h1-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	createZip := func(name string, entries []struct{ name, content string }) string {
+		zipPath := filepath.Join(tmpDir, name)
+		zipFile, err := os.Create(zipPath)
+		if err != nil {
+			t.Fatalf("Failed to create zip file: %v", err)
+		}
+		defer zipFile.Close()
+		zipWriter := zip.NewWriter(zipFile)
+		for _, entry := range entries {
+			w, err := zipWriter.Create(entry.name)
+			if err != nil {
+				t.Fatalf("Failed to create zip entry: %v", err)
+			}
+			if _, err := w.Write([]byte(entry.content)); err != nil {
+				t.Fatalf("Failed to write zip entry: %v", err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatalf("Failed to close zip writer: %v", err)
+		}
+		return zipPath
+	}
+
+	// Entries are written out of order to verify that they are sorted by name
+	zipPath := createZip("test.zip", []struct{ name, content string }{
+		{"terraform-provider-x_v1.0.0", "binary"},
+		{"a.txt", "hello\n"},
+	})
+
+	got, err := CalculateZipH1Hash(zipPath)
+	if err != nil {
+		t.Fatalf("CalculateZipH1Hash() error = %v", err)
+	}
+	want := "h1:mALBog5wuXqF9rWi/JLBkP5aWdbnT0qBQfa5bhww0tY="
+	if got != want {
+		t.Errorf("CalculateZipH1Hash() = %v, want %v", got, want)
+	}
+
+	// Directory entries do not contribute to the hash
+	dirZipPath := createZip("dir.zip", []struct{ name, content string }{
+		{"docs/", ""},
+		{"terraform-provider-x_v1.0.0", "binary"},
+		{"a.txt", "hello\n"},
+	})
+	got, err = CalculateZipH1Hash(dirZipPath)
+	if err != nil {
+		t.Fatalf("CalculateZipH1Hash() with directory entry error = %v", err)
+	}
+	if got != want {
+		t.Errorf("CalculateZipH1Hash() with directory entry = %v, want %v", got, want)
+	}
+
+	h1Path := zipPath + ".h1"
+	written, err := WriteH1HashFile(zipPath, h1Path)
+	if err != nil {
+		t.Fatalf("WriteH1HashFile() error = %v", err)
+	}
+	if written != want {
+		t.Errorf("WriteH1HashFile() = %v, want %v", written, want)
+	}
+	read, err := ReadH1HashFile(h1Path)
+	if err != nil {
+		t.Fatalf("ReadH1HashFile() error = %v", err)
+	}
+	if read != want {
+		t.Errorf("ReadH1HashFile() = %v, want %v", read, want)
+	}
+}
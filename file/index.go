@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
+
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
 )
 
 // VersionsIndex represents the structure of the versions index.json file.
@@ -21,6 +24,9 @@ type VersionInfo struct {
 	Version   string     `json:"version"`
 	Protocols []string   `json:"protocols"`
 	Platforms []Platform `json:"platforms"`
+	// PublishedAt is when the version was first published, kept while it is republished.
+	// It is not part of the registry protocol and is ignored by Terraform.
+	PublishedAt *time.Time `json:"published_at,omitempty"`
 }
 
 // Platform represents a platform entry in version info.
@@ -65,23 +71,18 @@ func ReadVersionsIndex(path string, id string) (*VersionsIndex, error) {
 }
 
 // AddVersion adds or updates a version in the index.
+// The protocols are used only when the version is newly created; use SetProtocols to update them.
 // Returns true if the version/platform was added, false if it already existed and was skipped.
-func (vi *VersionsIndex) AddVersion(version, os, arch string) bool {
+func (vi *VersionsIndex) AddVersion(version, os, arch string, protocols []string) bool {
 	// Check if this version already exists
-	var existingVersion *VersionInfo
-	for i := range vi.Versions {
-		if vi.Versions[i].Version == version {
-			existingVersion = &vi.Versions[i]
-			break
-		}
-	}
+	existingVersion := vi.FindVersion(version)
 
 	added := false
 	// If version doesn't exist, create it
 	if existingVersion == nil {
 		vi.Versions = append(vi.Versions, VersionInfo{
 			Version:   version,
-			Protocols: []string{"6.0"},
+			Protocols: append([]string{}, protocols...),
 			Platforms: []Platform{
 				{
 					OS:   os,
@@ -110,14 +111,83 @@ func (vi *VersionsIndex) AddVersion(version, os, arch string) bool {
 		}
 	}
 
+	// Keep platforms in a stable order regardless of the order they are added in
+	if added {
+		platforms := vi.FindVersion(version).Platforms
+		sort.SliceStable(platforms, func(i, j int) bool {
+			if platforms[i].OS != platforms[j].OS {
+				return platforms[i].OS < platforms[j].OS
+			}
+			return platforms[i].Arch < platforms[j].Arch
+		})
+	}
+
 	// Sort versions in descending order (newest first)
-	sort.Slice(vi.Versions, func(i, j int) bool {
-		return vi.Versions[i].Version > vi.Versions[j].Version
-	})
+	vi.SortVersions()
 
 	return added
 }
 
+// FindVersion returns the entry for the given version, or nil if it doesn't exist.
+func (vi *VersionsIndex) FindVersion(version string) *VersionInfo {
+	for i := range vi.Versions {
+		if vi.Versions[i].Version == version {
+			return &vi.Versions[i]
+		}
+	}
+	return nil
+}
+
+// RemovePlatform removes a platform of a version, and the version itself once no platforms are left.
+// Returns true if the platform was removed.
+func (vi *VersionsIndex) RemovePlatform(version, os, arch string) bool {
+	existingVersion := vi.FindVersion(version)
+	if existingVersion == nil {
+		return false
+	}
+
+	for i, platform := range existingVersion.Platforms {
+		if platform.OS == os && platform.Arch == arch {
+			existingVersion.Platforms = append(existingVersion.Platforms[:i], existingVersion.Platforms[i+1:]...)
+			if len(existingVersion.Platforms) == 0 {
+				vi.RemoveVersion(version)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveVersion removes a version with all its platforms.
+// Returns true if the version was removed.
+func (vi *VersionsIndex) RemoveVersion(version string) bool {
+	for i := range vi.Versions {
+		if vi.Versions[i].Version == version {
+			vi.Versions = append(vi.Versions[:i], vi.Versions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetProtocols replaces the protocols of an existing version.
+// Returns true if the protocols were changed.
+func (vi *VersionsIndex) SetProtocols(version string, protocols []string) bool {
+	existingVersion := vi.FindVersion(version)
+	if existingVersion == nil || EqualProtocols(existingVersion.Protocols, protocols) {
+		return false
+	}
+	existingVersion.Protocols = append([]string{}, protocols...)
+	return true
+}
+
+// SortVersions sorts versions in descending order of Semantic Versioning precedence (newest first).
+func (vi *VersionsIndex) SortVersions() {
+	sort.SliceStable(vi.Versions, func(i, j int) bool {
+		return semver.CompareStrings(vi.Versions[i].Version, vi.Versions[j].Version) > 0
+	})
+}
+
 // WriteVersionsIndex writes the versions index to a file.
 func WriteVersionsIndex(path string, index *VersionsIndex) error {
 	// Ensure directory exists
@@ -132,7 +202,7 @@ func WriteVersionsIndex(path string, index *VersionsIndex) error {
 	}
 
 	// Write to file
-	err = os.WriteFile(path, data, 0644)
+	err = WriteFileAtomic(path, data, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write versions index: %w", err)
 	}
@@ -40,7 +40,7 @@ func TestVersionsIndex(t *testing.T) {
 			t.Fatalf("ReadVersionsIndex error: %v", err)
 		}
 
-		index.AddVersion("1.0.0", "linux", "amd64")
+		index.AddVersion("1.0.0", "linux", "amd64", []string{"6.0"})
 		if err := WriteVersionsIndex(testIndexPath, index); err != nil {
 			t.Fatalf("WriteVersionsIndex error: %v", err)
 		}
@@ -75,7 +75,7 @@ func TestVersionsIndex(t *testing.T) {
 			t.Fatalf("ReadVersionsIndex error: %v", err)
 		}
 
-		index.AddVersion("1.0.0", "darwin", "arm64")
+		index.AddVersion("1.0.0", "darwin", "arm64", []string{"6.0"})
 		if err := WriteVersionsIndex(testIndexPath, index); err != nil {
 			t.Fatalf("WriteVersionsIndex error: %v", err)
 		}
@@ -126,7 +126,7 @@ func TestVersionsIndex(t *testing.T) {
 			t.Fatalf("ReadVersionsIndex error: %v", err)
 		}
 
-		index.AddVersion("2.0.0", "windows", "amd64")
+		index.AddVersion("2.0.0", "windows", "amd64", []string{"6.0"})
 		if err := WriteVersionsIndex(testIndexPath, index); err != nil {
 			t.Fatalf("WriteVersionsIndex error: %v", err)
 		}
@@ -199,3 +199,56 @@ func TestVersionsIndex(t *testing.T) {
 		}
 	})
 }
+
+func TestVersionsIndexSemVerOrdering(t *testing.T) {
+	index := &VersionsIndex{
+		ID:       "test",
+		Versions: []VersionInfo{},
+	}
+
+	for _, version := range []string{"1.9.0", "2.0.0-beta1", "1.10.0", "2.0.0", "0.1.0", "2.0.0-alpha"} {
+		index.AddVersion(version, "linux", "amd64", []string{"6.0"})
+	}
+
+	expected := []string{"2.0.0", "2.0.0-beta1", "2.0.0-alpha", "1.10.0", "1.9.0", "0.1.0"}
+	if len(index.Versions) != len(expected) {
+		t.Fatalf("Index has %d versions, want %d", len(index.Versions), len(expected))
+	}
+	for i, version := range expected {
+		if index.Versions[i].Version != version {
+			t.Errorf("Version at index %d = %s, want %s", i, index.Versions[i].Version, version)
+		}
+	}
+}
+
+func TestVersionsIndexRemovePlatform(t *testing.T) {
+	index := &VersionsIndex{
+		ID:       "test",
+		Versions: []VersionInfo{},
+	}
+	index.AddVersion("1.0.0", "linux", "amd64", []string{"6.0"})
+	index.AddVersion("1.0.0", "darwin", "arm64", []string{"6.0"})
+	index.AddVersion("1.1.0", "linux", "amd64", []string{"6.0"})
+
+	if index.RemovePlatform("1.0.0", "windows", "amd64") {
+		t.Error("RemovePlatform() of a missing platform = true, want false")
+	}
+
+	if !index.RemovePlatform("1.0.0", "darwin", "arm64") {
+		t.Error("RemovePlatform() = false, want true")
+	}
+	if ver := index.FindVersion("1.0.0"); ver == nil || len(ver.Platforms) != 1 {
+		t.Errorf("Version 1.0.0 = %+v, want a single platform", ver)
+	}
+
+	// The version is removed with its last platform
+	if !index.RemovePlatform("1.0.0", "linux", "amd64") {
+		t.Error("RemovePlatform() = false, want true")
+	}
+	if ver := index.FindVersion("1.0.0"); ver != nil {
+		t.Errorf("Version 1.0.0 = %+v, want removed", ver)
+	}
+	if len(index.Versions) != 1 || index.Versions[0].Version != "1.1.0" {
+		t.Errorf("Versions = %+v, want only 1.1.0", index.Versions)
+	}
+}
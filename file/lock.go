@@ -0,0 +1,26 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatLockProvider formats a provider block of a Terraform dependency lock file (.terraform.lock.hcl).
+// constraints is omitted when empty.
+func FormatLockProvider(address, version, constraints string, hashes []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "provider %q {\n", address)
+	if constraints != "" {
+		fmt.Fprintf(&sb, "  version     = %q\n", version)
+		fmt.Fprintf(&sb, "  constraints = %q\n", constraints)
+	} else {
+		fmt.Fprintf(&sb, "  version = %q\n", version)
+	}
+	sb.WriteString("  hashes = [\n")
+	for _, hash := range hashes {
+		fmt.Fprintf(&sb, "    %q,\n", hash)
+	}
+	sb.WriteString("  ]\n")
+	sb.WriteString("}\n")
+	return sb.String()
+}
@@ -0,0 +1,47 @@
+package file
+
+import "testing"
+
+func TestFormatLockProvider(t *testing.T) {
+	hashes := []string{"h1:abc=", "zh:def"}
+
+	tests := []struct {
+		name        string
+		constraints string
+		want        string
+	}{
+		{
+			name: "without constraints",
+			want: `provider "registry.example.com/example/test" {
+  version = "1.0.0"
+  hashes = [
+    "h1:abc=",
+    "zh:def",
+  ]
+}
+`,
+		},
+		{
+			name:        "with constraints",
+			constraints: "~> 1.0",
+			want: `provider "registry.example.com/example/test" {
+  version     = "1.0.0"
+  constraints = "~> 1.0"
+  hashes = [
+    "h1:abc=",
+    "zh:def",
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatLockProvider("registry.example.com/example/test", "1.0.0", tt.constraints, hashes)
+			if got != tt.want {
+				t.Errorf("FormatLockProvider() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
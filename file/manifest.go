@@ -0,0 +1,137 @@
+// Package file provides utilities for file operations needed by the Terraform registry builder.
+package file
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+)
+
+// RegistryManifestFileName is the name of the manifest file shipped with providers,
+// e.g., by goreleaser or inside provider packages.
+const RegistryManifestFileName = "terraform-registry-manifest.json"
+
+// DefaultProtocols is the list of protocol versions used when no manifest is available.
+var DefaultProtocols = []string{"6.0"}
+
+// protocolRegex matches protocol versions like "5.0" or "6.0".
+var protocolRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
+// RegistryManifest represents the structure of the terraform-registry-manifest.json file.
+type RegistryManifest struct {
+	Version  int                      `json:"version"`
+	Metadata RegistryManifestMetadata `json:"metadata"`
+}
+
+// RegistryManifestMetadata represents the metadata object in the registry manifest.
+type RegistryManifestMetadata struct {
+	ProtocolVersions []string `json:"protocol_versions"`
+}
+
+// ParseRegistryManifest parses the content of a registry manifest and validates it.
+func ParseRegistryManifest(data []byte) (*RegistryManifest, error) {
+	var manifest RegistryManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse registry manifest: %w", err)
+	}
+
+	if manifest.Version != 1 {
+		return nil, fmt.Errorf("unsupported registry manifest version: %d", manifest.Version)
+	}
+
+	if err := ValidateProtocols(manifest.Metadata.ProtocolVersions); err != nil {
+		return nil, fmt.Errorf("invalid registry manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+// ReadRegistryManifest reads a registry manifest file.
+func ReadRegistryManifest(path string) (*RegistryManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry manifest: %w", err)
+	}
+
+	return ParseRegistryManifest(data)
+}
+
+// WriteRegistryManifest writes a registry manifest file declaring the protocol versions.
+func WriteRegistryManifest(path string, protocols []string) error {
+	manifest := RegistryManifest{
+		Version:  1,
+		Metadata: RegistryManifestMetadata{ProtocolVersions: append([]string{}, protocols...)},
+	}
+	data, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry manifest: %w", err)
+	}
+
+	if err := WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write registry manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadRegistryManifestFromZip reads the registry manifest stored at the top level of a zip file.
+// Returns nil without error if the zip file doesn't contain a manifest.
+func ReadRegistryManifestFromZip(zipPath string) (*RegistryManifest, error) {
+	zipReader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer zipReader.Close()
+
+	for _, f := range zipReader.File {
+		if path.Clean(f.Name) != RegistryManifestFileName {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open registry manifest in zip: %w", err)
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read registry manifest in zip: %w", err)
+		}
+
+		return ParseRegistryManifest(data)
+	}
+
+	return nil, nil
+}
+
+// ValidateProtocols checks that the list of protocol versions is not empty and well-formed.
+func ValidateProtocols(protocols []string) error {
+	if len(protocols) == 0 {
+		return fmt.Errorf("no protocol versions specified")
+	}
+
+	for _, protocol := range protocols {
+		if !protocolRegex.MatchString(protocol) {
+			return fmt.Errorf("invalid protocol version: %q", protocol)
+		}
+	}
+
+	return nil
+}
+
+// EqualProtocols returns whether two lists of protocol versions are identical.
+func EqualProtocols(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package file
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRegistryManifest(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantProtocols []string
+		wantErr       bool
+	}{
+		{
+			name:          "protocol 5",
+			content:       `{"version": 1, "metadata": {"protocol_versions": ["5.0"]}}`,
+			wantProtocols: []string{"5.0"},
+		},
+		{
+			name:          "multiple protocols",
+			content:       `{"version": 1, "metadata": {"protocol_versions": ["5.0", "6.0"]}}`,
+			wantProtocols: []string{"5.0", "6.0"},
+		},
+		{
+			name:    "unsupported manifest version",
+			content: `{"version": 2, "metadata": {"protocol_versions": ["6.0"]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "no protocols",
+			content: `{"version": 1, "metadata": {}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid protocol",
+			content: `{"version": 1, "metadata": {"protocol_versions": ["v6"]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			content: `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := ParseRegistryManifest([]byte(tt.content))
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRegistryManifest() error = nil, wantErr = true")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseRegistryManifest() error = %v, wantErr = false", err)
+			}
+
+			if !EqualProtocols(manifest.Metadata.ProtocolVersions, tt.wantProtocols) {
+				t.Errorf("Protocols = %v, want %v", manifest.Metadata.ProtocolVersions, tt.wantProtocols)
+			}
+		})
+	}
+}
+
+func TestWriteRegistryManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform-provider-example_1.0.0_manifest.json")
+	if err := WriteRegistryManifest(path, []string{"5.0", "6.0"}); err != nil {
+		t.Fatalf("WriteRegistryManifest() error = %v", err)
+	}
+
+	manifest, err := ReadRegistryManifest(path)
+	if err != nil {
+		t.Fatalf("ReadRegistryManifest() error = %v", err)
+	}
+	if !EqualProtocols(manifest.Metadata.ProtocolVersions, []string{"5.0", "6.0"}) {
+		t.Errorf("Protocols = %v, want [5.0 6.0]", manifest.Metadata.ProtocolVersions)
+	}
+}
+
+func TestReadRegistryManifestFromZip(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "manifest_test")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeZip := func(t *testing.T, path string, files map[string]string) {
+		zipFile, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("Failed to create zip file: %v", err)
+		}
+		defer zipFile.Close()
+
+		zipWriter := zip.NewWriter(zipFile)
+		for name, content := range files {
+			w, err := zipWriter.Create(name)
+			if err != nil {
+				t.Fatalf("Failed to create zip entry: %v", err)
+			}
+			if _, err := w.Write([]byte(content)); err != nil {
+				t.Fatalf("Failed to write zip entry: %v", err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatalf("Failed to close zip writer: %v", err)
+		}
+	}
+
+	t.Run("zip with manifest", func(t *testing.T) {
+		zipPath := filepath.Join(tmpDir, "with.zip")
+		writeZip(t, zipPath, map[string]string{
+			"terraform-provider-test_v1.0.0":   "binary",
+			"terraform-registry-manifest.json": `{"version": 1, "metadata": {"protocol_versions": ["5.0"]}}`,
+		})
+
+		manifest, err := ReadRegistryManifestFromZip(zipPath)
+		if err != nil {
+			t.Fatalf("ReadRegistryManifestFromZip() error = %v", err)
+		}
+		if manifest == nil {
+			t.Fatal("ReadRegistryManifestFromZip() = nil, want manifest")
+		}
+		if !EqualProtocols(manifest.Metadata.ProtocolVersions, []string{"5.0"}) {
+			t.Errorf("Protocols = %v, want [5.0]", manifest.Metadata.ProtocolVersions)
+		}
+	})
+
+	t.Run("zip without manifest", func(t *testing.T) {
+		zipPath := filepath.Join(tmpDir, "without.zip")
+		writeZip(t, zipPath, map[string]string{
+			"terraform-provider-test_v1.0.0": "binary",
+		})
+
+		manifest, err := ReadRegistryManifestFromZip(zipPath)
+		if err != nil {
+			t.Fatalf("ReadRegistryManifestFromZip() error = %v", err)
+		}
+		if manifest != nil {
+			t.Errorf("ReadRegistryManifestFromZip() = %v, want nil", manifest)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// MirrorIndex represents the index.json file of a provider in a network mirror,
+// listing the available versions.
+type MirrorIndex struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+// MirrorVersion represents the <version>.json file of a provider in a network mirror,
+// listing the packages of the version keyed by "<os>_<arch>".
+type MirrorVersion struct {
+	Archives map[string]MirrorArchive `json:"archives"`
+}
+
+// MirrorArchive describes a provider package in a network mirror.
+type MirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes,omitempty"`
+}
+
+// ReadMirrorIndex reads the index.json file of a provider in a network mirror.
+// An empty index is returned if the file does not exist.
+func ReadMirrorIndex(path string) (*MirrorIndex, error) {
+	index := MirrorIndex{}
+	if err := readMirrorFile(path, &index); err != nil {
+		return nil, err
+	}
+	if index.Versions == nil {
+		index.Versions = map[string]struct{}{}
+	}
+	return &index, nil
+}
+
+// AddVersion adds a version to the index. Returns true if the version was added.
+func (idx *MirrorIndex) AddVersion(version string) bool {
+	if _, ok := idx.Versions[version]; ok {
+		return false
+	}
+	idx.Versions[version] = struct{}{}
+	return true
+}
+
+// RemoveVersion removes a version from the index. Returns true if the version was removed.
+func (idx *MirrorIndex) RemoveVersion(version string) bool {
+	if _, ok := idx.Versions[version]; !ok {
+		return false
+	}
+	delete(idx.Versions, version)
+	return true
+}
+
+// WriteMirrorIndex writes the index.json file of a provider in a network mirror.
+func WriteMirrorIndex(path string, index *MirrorIndex) error {
+	return writeMirrorFile(path, index)
+}
+
+// ReadMirrorVersion reads the <version>.json file of a provider in a network mirror.
+// An empty version is returned if the file does not exist.
+func ReadMirrorVersion(path string) (*MirrorVersion, error) {
+	version := MirrorVersion{}
+	if err := readMirrorFile(path, &version); err != nil {
+		return nil, err
+	}
+	if version.Archives == nil {
+		version.Archives = map[string]MirrorArchive{}
+	}
+	return &version, nil
+}
+
+// WriteMirrorVersion writes the <version>.json file of a provider in a network mirror.
+func WriteMirrorVersion(path string, version *MirrorVersion) error {
+	return writeMirrorFile(path, version)
+}
+
+// readMirrorFile reads a JSON file of a network mirror. Missing files are left as is.
+func readMirrorFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read network mirror file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse network mirror file %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeMirrorFile writes a JSON file of a network mirror.
+func writeMirrorFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal network mirror file: %w", err)
+	}
+
+	if err := WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write network mirror file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMirrorFiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mirror-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	indexPath := filepath.Join(tmpDir, "index.json")
+	index, err := ReadMirrorIndex(indexPath)
+	if err != nil {
+		t.Fatalf("ReadMirrorIndex() error = %v", err)
+	}
+	if len(index.Versions) != 0 {
+		t.Errorf("Versions = %v, want empty", index.Versions)
+	}
+	if !index.AddVersion("1.0.0") {
+		t.Error("AddVersion() = false, want true")
+	}
+	if index.AddVersion("1.0.0") {
+		t.Error("AddVersion() for an existing version = true, want false")
+	}
+	if err := WriteMirrorIndex(indexPath, index); err != nil {
+		t.Fatalf("WriteMirrorIndex() error = %v", err)
+	}
+
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("Failed to read index.json: %v", err)
+	}
+	if !strings.Contains(string(data), `"1.0.0": {}`) {
+		t.Errorf("index.json = %s, want the version as an empty object", data)
+	}
+
+	versionPath := filepath.Join(tmpDir, "1.0.0.json")
+	version, err := ReadMirrorVersion(versionPath)
+	if err != nil {
+		t.Fatalf("ReadMirrorVersion() error = %v", err)
+	}
+	version.Archives["linux_amd64"] = MirrorArchive{
+		URL:    "terraform-provider-test_1.0.0_linux_amd64.zip",
+		Hashes: []string{"h1:abc=", "zh:def"},
+	}
+	if err := WriteMirrorVersion(versionPath, version); err != nil {
+		t.Fatalf("WriteMirrorVersion() error = %v", err)
+	}
+
+	read, err := ReadMirrorVersion(versionPath)
+	if err != nil {
+		t.Fatalf("ReadMirrorVersion() error = %v", err)
+	}
+	archive, ok := read.Archives["linux_amd64"]
+	if !ok {
+		t.Fatalf("Archives = %v, want linux_amd64", read.Archives)
+	}
+	if archive.URL != "terraform-provider-test_1.0.0_linux_amd64.zip" || len(archive.Hashes) != 2 {
+		t.Errorf("Archive = %+v", archive)
+	}
+}
@@ -0,0 +1,130 @@
+// Package file provides utilities for file operations needed by the Terraform registry builder.
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
+)
+
+// ModuleVersionsIndex represents the structure of the module versions index.json file.
+type ModuleVersionsIndex struct {
+	Modules []ModuleVersions `json:"modules"`
+}
+
+// ModuleVersions represents the versions of a single module in the module versions index.
+type ModuleVersions struct {
+	Versions []ModuleVersion `json:"versions"`
+}
+
+// ModuleVersion represents a single version entry in the module versions index.
+type ModuleVersion struct {
+	Version string `json:"version"`
+}
+
+// ModuleDownloadIndex represents the structure of the module download index.json file.
+// Terraform reads the location from the response body when the X-Terraform-Get header is absent.
+type ModuleDownloadIndex struct {
+	Location string `json:"location"`
+}
+
+// ReadModuleVersionsIndex reads a module versions index.json file if it exists, otherwise returns a new empty one.
+func ReadModuleVersionsIndex(path string) (*ModuleVersionsIndex, error) {
+	// Check if file exists
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Return a new empty index
+		return &ModuleVersionsIndex{
+			Modules: []ModuleVersions{{Versions: []ModuleVersion{}}},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module versions index file: %w", err)
+	}
+
+	// Parse the JSON
+	var index ModuleVersionsIndex
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse module versions index file: %w", err)
+		}
+	}
+	if len(index.Modules) == 0 {
+		index.Modules = []ModuleVersions{{Versions: []ModuleVersion{}}}
+	}
+
+	return &index, nil
+}
+
+// HasVersion returns whether the version exists in the index.
+func (mi *ModuleVersionsIndex) HasVersion(version string) bool {
+	for _, v := range mi.Modules[0].Versions {
+		if v.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// AddVersion adds a version to the index.
+// Returns true if the version was added, false if it already existed.
+func (mi *ModuleVersionsIndex) AddVersion(version string) bool {
+	if mi.HasVersion(version) {
+		return false
+	}
+
+	mi.Modules[0].Versions = append(mi.Modules[0].Versions, ModuleVersion{Version: version})
+
+	// Sort versions in descending order (newest first)
+	sort.SliceStable(mi.Modules[0].Versions, func(i, j int) bool {
+		return semver.CompareStrings(mi.Modules[0].Versions[i].Version, mi.Modules[0].Versions[j].Version) > 0
+	})
+
+	return true
+}
+
+// WriteModuleVersionsIndex writes the module versions index to a file.
+func WriteModuleVersionsIndex(path string, index *ModuleVersionsIndex) error {
+	// Ensure directory exists
+	if err := EnsureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create directory for module versions index: %w", err)
+	}
+
+	// Marshal to JSON with indentation
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal module versions index: %w", err)
+	}
+
+	// Write to file
+	if err := WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write module versions index: %w", err)
+	}
+
+	return nil
+}
+
+// WriteModuleDownloadIndex writes the module download index.json file pointing to the archive location.
+func WriteModuleDownloadIndex(path string, location string) error {
+	// Ensure directory exists
+	if err := EnsureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create directory for module download index: %w", err)
+	}
+
+	// Marshal to JSON with indentation
+	data, err := json.MarshalIndent(ModuleDownloadIndex{Location: location}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal module download index: %w", err)
+	}
+
+	// Write to file
+	if err := WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write module download index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package file
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleVersionsIndex(t *testing.T) {
+	// Create temporary directory for tests
+	testDir, err := os.MkdirTemp("", "module_index_test")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	testIndexPath := filepath.Join(testDir, "versions", "index.json")
+
+	index, err := ReadModuleVersionsIndex(testIndexPath)
+	if err != nil {
+		t.Fatalf("ReadModuleVersionsIndex error: %v", err)
+	}
+
+	for _, version := range []string{"1.9.0", "1.10.0", "1.0.0-rc.1"} {
+		if !index.AddVersion(version) {
+			t.Errorf("AddVersion(%s) = false, want true", version)
+		}
+	}
+	if index.AddVersion("1.9.0") {
+		t.Error("AddVersion(1.9.0) = true for an existing version, want false")
+	}
+
+	if err := WriteModuleVersionsIndex(testIndexPath, index); err != nil {
+		t.Fatalf("WriteModuleVersionsIndex error: %v", err)
+	}
+
+	// Verify the JSON follows the module registry protocol
+	data, err := os.ReadFile(testIndexPath)
+	if err != nil {
+		t.Fatalf("Failed to read module versions index: %v", err)
+	}
+	var raw struct {
+		Modules []struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		} `json:"modules"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Failed to parse module versions index: %v", err)
+	}
+	if len(raw.Modules) != 1 {
+		t.Fatalf("Index has %d modules, want 1", len(raw.Modules))
+	}
+
+	expected := []string{"1.10.0", "1.9.0", "1.0.0-rc.1"}
+	if len(raw.Modules[0].Versions) != len(expected) {
+		t.Fatalf("Index has %d versions, want %d", len(raw.Modules[0].Versions), len(expected))
+	}
+	for i, version := range expected {
+		if raw.Modules[0].Versions[i].Version != version {
+			t.Errorf("Version at index %d = %s, want %s", i, raw.Modules[0].Versions[i].Version, version)
+		}
+	}
+
+	// Read it back
+	index, err = ReadModuleVersionsIndex(testIndexPath)
+	if err != nil {
+		t.Fatalf("ReadModuleVersionsIndex error: %v", err)
+	}
+	if !index.HasVersion("1.10.0") {
+		t.Error("HasVersion(1.10.0) = false, want true")
+	}
+}
+
+func TestWriteModuleDownloadIndex(t *testing.T) {
+	// Create temporary directory for tests
+	testDir, err := os.MkdirTemp("", "module_index_test")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	path := filepath.Join(testDir, "download", "index.json")
+	if err := WriteModuleDownloadIndex(path, "/v1/modules/a/b/c/1.0.0/a-b-c_v1.0.0.tar.gz"); err != nil {
+		t.Fatalf("WriteModuleDownloadIndex error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read module download index: %v", err)
+	}
+	var index ModuleDownloadIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Failed to parse module download index: %v", err)
+	}
+	if index.Location != "/v1/modules/a/b/c/1.0.0/a-b-c_v1.0.0.tar.gz" {
+		t.Errorf("Location = %q, want %q", index.Location, "/v1/modules/a/b/c/1.0.0/a-b-c_v1.0.0.tar.gz")
+	}
+}
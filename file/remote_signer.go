@@ -0,0 +1,142 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteSignerTimeout is the timeout of a request to a signing service.
+const RemoteSignerTimeout = time.Minute
+
+// maxRemoteResponseSize is the maximum size of a response of a signing service.
+const maxRemoteResponseSize = 1 << 20
+
+// RemoteSigner signs by a signing service over HTTP.
+// The data to sign is posted to the sign URL, which responds with the detached signature,
+// either binary or ASCII-armored. The public key URL responds with the ASCII-armored public key.
+// Requests are authenticated with a bearer token.
+type RemoteSigner struct {
+	signURL      string
+	publicKeyURL string
+	token        string
+	keyID        string
+	client       *http.Client
+
+	mu        sync.Mutex
+	publicKey *GPGPublicKey
+}
+
+// NewRemoteSigner returns a signer using the signing service at the URLs, authenticated with the token.
+// The key ID defaults to the last 16 characters of the fingerprint of the public key.
+func NewRemoteSigner(signURL, publicKeyURL, token, keyID string) (*RemoteSigner, error) {
+	for name, rawURL := range map[string]string{"sign": signURL, "public key": publicKeyURL} {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s URL %q: %w", name, rawURL, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("%s URL must be http or https: %q", name, rawURL)
+		}
+	}
+	return &RemoteSigner{
+		signURL:      signURL,
+		publicKeyURL: publicKeyURL,
+		token:        token,
+		keyID:        keyID,
+		client:       &http.Client{Timeout: RemoteSignerTimeout},
+	}, nil
+}
+
+// Sign posts the data to the signing service, returning the signature in the binary form.
+// The signature is verified with the public key, so that a misconfigured service does not
+// produce signatures Terraform rejects.
+func (s *RemoteSigner) Sign(data []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, s.signURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", "application/pgp-signature")
+
+	signature, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) == 0 {
+		return nil, fmt.Errorf("%s returned no signature", s.signURL)
+	}
+	signature, err = binarySignature(signature)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.signURL, err)
+	}
+
+	publicKey, err := s.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDetached(data, signature, []GPGPublicKey{*publicKey}); err != nil {
+		return nil, fmt.Errorf("signature returned by %s cannot be verified with GPG key %s from %s: %w", s.signURL, publicKey.KeyID, s.publicKeyURL, err)
+	}
+	return signature, nil
+}
+
+// PublicKey fetches the public key from the signing service once, and returns it.
+func (s *RemoteSigner) PublicKey() (*GPGPublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.publicKey != nil {
+		return s.publicKey, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.publicKeyURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create public key request: %w", err)
+	}
+	req.Header.Set("Accept", "application/pgp-keys")
+
+	armored, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := newGPGPublicKey(string(armored), s.keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.publicKeyURL, err)
+	}
+	s.publicKey = publicKey
+	return publicKey, nil
+}
+
+// do sends the request with the bearer token, and returns the body of the successful response.
+func (s *RemoteSigner) do(req *http.Request) ([]byte, error) {
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("signing service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", req.URL, err)
+	}
+	if len(body) > maxRemoteResponseSize {
+		return nil, fmt.Errorf("response from %s exceeds %d bytes", req.URL, maxRemoteResponseSize)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message := strings.TrimSpace(string(body))
+		if len(message) > 200 {
+			message = message[:200] + "..."
+		}
+		return nil, fmt.Errorf("%s %s returned %s: %s", req.Method, req.URL, resp.Status, message)
+	}
+	return body, nil
+}
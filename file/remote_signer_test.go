@@ -0,0 +1,163 @@
+package file
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/armor"
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	cleanup := SetupTestGPG(t)
+	defer cleanup()
+
+	// Another key a misconfigured service signs with
+	otherKey, err := crypto.PGP().KeyGeneration().AddUserId("terraform-registry-builder-other", "other@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate GPG key: %v", err)
+	}
+	otherSigner, err := crypto.PGP().Sign().SigningKey(otherKey).Detached().New()
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	// Stand-in of a signing service signing with the key in environment variables
+	var publicKeyRequests, signRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/sign":
+			signRequests.Add(1)
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			signature, err := EnvSigner{}.Sign(data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			armored, err := armor.ArmorPGPSignature(signature)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			io.WriteString(w, armored)
+		case r.Method == http.MethodPost && r.URL.Path == "/sign-other-key":
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			signature, err := otherSigner.Sign(data, crypto.Bytes)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Write(signature)
+		case r.Method == http.MethodPost && r.URL.Path == "/sign-error-page":
+			io.WriteString(w, "<html><body>Service Unavailable</body></html>")
+		case r.Method == http.MethodGet && r.URL.Path == "/public-key":
+			publicKeyRequests.Add(1)
+			publicKey, err := EnvSigner{}.PublicKey()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			io.WriteString(w, publicKey.ASCIIArmor)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tmpDir, err := os.MkdirTemp("", "remote-signer-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filePath := filepath.Join(tmpDir, "SHA256SUMS")
+	if err := os.WriteFile(filePath, []byte("1111  a.zip\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	signer, err := NewRemoteSigner(server.URL+"/sign", server.URL+"/public-key", "secret-token", "")
+	if err != nil {
+		t.Fatalf("NewRemoteSigner error: %v", err)
+	}
+	// Dry run only fetches the public key
+	keyID, err := ValidateSignerDryRun(signer)
+	if err != nil {
+		t.Fatalf("ValidateSignerDryRun error: %v", err)
+	}
+	if keyID != os.Getenv("TFREGBUILDER_GPG_ID") {
+		t.Errorf("Key ID in dry run = %s, want %s", keyID, os.Getenv("TFREGBUILDER_GPG_ID"))
+	}
+	if n := signRequests.Load(); n != 0 {
+		t.Errorf("Sign requests in dry run = %d, want 0", n)
+	}
+
+	keyID, err = ValidateSigner(signer)
+	if err != nil {
+		t.Fatalf("ValidateSigner error: %v", err)
+	}
+	if keyID != os.Getenv("TFREGBUILDER_GPG_ID") {
+		t.Errorf("Key ID = %s, want %s", keyID, os.Getenv("TFREGBUILDER_GPG_ID"))
+	}
+
+	sigPath := filePath + ".sig"
+	if err := SignFile(signer, filePath, sigPath); err != nil {
+		t.Fatalf("SignFile error: %v", err)
+	}
+	publicKey, err := signer.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey error: %v", err)
+	}
+	if err := VerifySignature(filePath, sigPath, []GPGPublicKey{*publicKey}); err != nil {
+		t.Errorf("VerifySignature error: %v", err)
+	}
+	if n := publicKeyRequests.Load(); n != 1 {
+		t.Errorf("Public key requests = %d, want 1", n)
+	}
+
+	// Signatures that cannot be verified with the public key are rejected
+	for _, path := range []string{"/sign-other-key", "/sign-error-page"} {
+		misconfigured, err := NewRemoteSigner(server.URL+path, server.URL+"/public-key", "secret-token", "")
+		if err != nil {
+			t.Fatalf("NewRemoteSigner error: %v", err)
+		}
+		if err := SignFile(misconfigured, filePath, filepath.Join(tmpDir, "misconfigured.sig")); err == nil {
+			t.Errorf("SignFile with %s succeeded, want error", path)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "misconfigured.sig")); !os.IsNotExist(err) {
+			t.Errorf("Signature file from %s was written", path)
+		}
+	}
+
+	// Rejected requests are reported
+	unauthorized, err := NewRemoteSigner(server.URL+"/sign", server.URL+"/public-key", "wrong-token", "")
+	if err != nil {
+		t.Fatalf("NewRemoteSigner error: %v", err)
+	}
+	if _, err := unauthorized.Sign([]byte("data")); err == nil {
+		t.Error("Sign with a wrong token succeeded, want error")
+	}
+	if _, err := unauthorized.PublicKey(); err == nil {
+		t.Error("PublicKey with a wrong token succeeded, want error")
+	}
+
+	if _, err := NewRemoteSigner("ftp://example.com/sign", server.URL+"/public-key", "", ""); err == nil {
+		t.Error("NewRemoteSigner with an ftp URL succeeded, want error")
+	}
+}
@@ -0,0 +1,260 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/gopenpgp/v3/armor"
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+// Signer makes the detached OpenPGP signatures of SHA256SUMS files.
+type Signer interface {
+	// Sign returns the binary detached signature of the data.
+	Sign(data []byte) ([]byte, error)
+	// PublicKey returns the public key verifying the signatures, as listed in download index.json files.
+	PublicKey() (*GPGPublicKey, error)
+}
+
+// SignFile signs a file with the signer, writing the detached signature to signaturePath.
+func SignFile(signer Signer, filePath, signaturePath string) error {
+	// Read the file to sign
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file to sign: %w", err)
+	}
+
+	signature, err := signer.Sign(fileData)
+	if err != nil {
+		return fmt.Errorf("failed to sign file: %w", err)
+	}
+
+	// Write signature to file
+	if err := WriteFileAtomic(signaturePath, signature, 0644); err != nil {
+		return fmt.Errorf("failed to write signature file: %w", err)
+	}
+	return nil
+}
+
+// ValidateSigner verifies that the signer can sign files and provide its public key,
+// signing empty data that is not written anywhere. Returns the key ID.
+func ValidateSigner(signer Signer) (string, error) {
+	publicKey, err := signer.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	if _, err := signer.Sign(nil); err != nil {
+		return "", fmt.Errorf("GPG key %s cannot be used to sign: %w", publicKey.KeyID, err)
+	}
+	return publicKey.KeyID, nil
+}
+
+// DryRunValidator is implemented by signers that can verify in dry-run mode that they can sign,
+// as doing so has no effects outside the process.
+type DryRunValidator interface {
+	// ValidateDryRun verifies that the signer can sign files.
+	ValidateDryRun() error
+}
+
+// ValidateSignerDryRun verifies the signer in dry-run mode, returning the key ID.
+// Signers are only asked for the public key unless they implement DryRunValidator,
+// so that a plan does not send signing requests or run signing commands.
+func ValidateSignerDryRun(signer Signer) (string, error) {
+	publicKey, err := signer.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	if validator, ok := signer.(DryRunValidator); ok {
+		if err := validator.ValidateDryRun(); err != nil {
+			return "", fmt.Errorf("GPG key %s cannot be used to sign: %w", publicKey.KeyID, err)
+		}
+	}
+	return publicKey.KeyID, nil
+}
+
+// EnvSigner signs in-process with the GPG private key in the TFREGBUILDER_GPG_* environment variables.
+// The key is read from the environment on every use.
+type EnvSigner struct{}
+
+// Sign returns the binary detached signature of the data.
+func (EnvSigner) Sign(data []byte) ([]byte, error) {
+	key, keyID, err := loadSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if !key.IsPrivate() {
+		return nil, fmt.Errorf("GPG key %s is not a private key", keyID)
+	}
+
+	// Create a signer
+	signer, err := crypto.PGP().Sign().SigningKey(key).Detached().New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signer: %w", err)
+	}
+	defer signer.ClearPrivateParams()
+
+	// Sign the data (armor=false for binary output)
+	return signer.Sign(data, crypto.Bytes)
+}
+
+// PublicKey returns the public key of the GPG private key.
+func (EnvSigner) PublicKey() (*GPGPublicKey, error) {
+	privateKey, _, keyID, err := GetGPGPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := GetPublicKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key: %w", err)
+	}
+	return &GPGPublicKey{KeyID: keyID, ASCIIArmor: publicKey}, nil
+}
+
+// ValidateDryRun verifies that the GPG private key can sign, signing empty data in-process.
+func (s EnvSigner) ValidateDryRun() error {
+	_, err := s.Sign(nil)
+	return err
+}
+
+// loadSigningKey loads the GPG private key from environment variables and unlocks it.
+// Returns the key and its ID.
+func loadSigningKey() (*crypto.Key, string, error) {
+	// Get GPG key information
+	privateKeyArmored, passphrase, keyID, err := GetGPGPrivateKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Parse the private key
+	key, err := crypto.NewKeyFromArmored(privateKeyArmored)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	// Unlock the key with passphrase if provided
+	if passphrase != "" {
+		isLocked, err := key.IsLocked()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to check if key is locked: %w", err)
+		}
+
+		if isLocked {
+			key, err = key.Unlock([]byte(passphrase))
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to unlock private key: %w", err)
+			}
+		}
+	}
+
+	return key, keyID, nil
+}
+
+// CommandSigner signs by running external commands, e.g., gpg using a key kept in gpg-agent.
+type CommandSigner struct {
+	signCommand      []string
+	publicKeyCommand []string
+	keyID            string
+
+	mu        sync.Mutex
+	publicKey *GPGPublicKey
+}
+
+// NewCommandSigner returns a signer running signCommand, which reads the data from the standard input
+// and writes the detached signature to the standard output, e.g., gpg --batch --detach-sign --local-user KEY.
+// publicKeyCommand writes the ASCII-armored public key to the standard output, e.g., gpg --armor --export KEY.
+// The key ID defaults to the last 16 characters of the fingerprint of the public key.
+func NewCommandSigner(signCommand, publicKeyCommand []string, keyID string) (*CommandSigner, error) {
+	if len(signCommand) == 0 {
+		return nil, fmt.Errorf("sign command is not specified")
+	}
+	if len(publicKeyCommand) == 0 {
+		return nil, fmt.Errorf("public key command is not specified")
+	}
+	return &CommandSigner{
+		signCommand:      signCommand,
+		publicKeyCommand: publicKeyCommand,
+		keyID:            keyID,
+	}, nil
+}
+
+// Sign runs the sign command with the data, returning the signature in the binary form.
+func (s *CommandSigner) Sign(data []byte) ([]byte, error) {
+	signature, err := runCommand(s.signCommand, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) == 0 {
+		return nil, fmt.Errorf("%s wrote no signature", s.signCommand[0])
+	}
+	return binarySignature(signature)
+}
+
+// PublicKey runs the public key command once, and returns the public key.
+func (s *CommandSigner) PublicKey() (*GPGPublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.publicKey != nil {
+		return s.publicKey, nil
+	}
+
+	output, err := runCommand(s.publicKeyCommand, nil)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := newGPGPublicKey(string(output), s.keyID)
+	if err != nil {
+		return nil, err
+	}
+	s.publicKey = publicKey
+	return publicKey, nil
+}
+
+// runCommand runs the command with the input, and returns its standard output.
+func runCommand(command []string, input []byte) ([]byte, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// newGPGPublicKey returns the public key for download index.json files from an ASCII-armored public key.
+// The key ID defaults to the last 16 characters of the fingerprint of the key.
+func newGPGPublicKey(armored, keyID string) (*GPGPublicKey, error) {
+	key, err := crypto.NewKeyFromArmored(armored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("a private key is given instead of a public key")
+	}
+	if keyID == "" {
+		fingerprint := key.GetFingerprint()
+		if len(fingerprint) < 16 {
+			return nil, fmt.Errorf("could not extract key ID from the public key")
+		}
+		keyID = fingerprint[len(fingerprint)-16:]
+	}
+	return &GPGPublicKey{KeyID: keyID, ASCIIArmor: armored}, nil
+}
+
+// binarySignature returns the signature in the binary form, removing the ASCII armor if any.
+func binarySignature(signature []byte) ([]byte, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN PGP SIGNATURE-----")) {
+		return signature, nil
+	}
+	binary, err := armor.UnarmorBytes(signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unarmor signature: %w", err)
+	}
+	return binary, nil
+}
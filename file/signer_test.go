@@ -0,0 +1,117 @@
+package file
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/armor"
+)
+
+// TestCommandSignerHelper is run as the external command of CommandSigner by TestCommandSigner.
+// It signs the standard input with the key in environment variables, writing an armored signature,
+// or writes the public key.
+func TestCommandSignerHelper(t *testing.T) {
+	if os.Getenv("TFREGBUILDER_TEST_SIGNER_HELPER") == "" || flag.NArg() != 1 {
+		t.Skip("run by TestCommandSigner")
+	}
+
+	switch flag.Arg(0) {
+	case "sign":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			t.Fatalf("Failed to read input: %v", err)
+		}
+		signature, err := EnvSigner{}.Sign(data)
+		if err != nil {
+			t.Fatalf("Sign error: %v", err)
+		}
+		armored, err := armor.ArmorPGPSignature(signature)
+		if err != nil {
+			t.Fatalf("Failed to armor signature: %v", err)
+		}
+		os.Stdout.WriteString(armored)
+	case "public-key":
+		publicKey, err := EnvSigner{}.PublicKey()
+		if err != nil {
+			t.Fatalf("PublicKey error: %v", err)
+		}
+		os.Stdout.WriteString(publicKey.ASCIIArmor)
+	case "fail":
+		os.Stderr.WriteString("no secret key")
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+func TestCommandSigner(t *testing.T) {
+	cleanup := SetupTestGPG(t)
+	defer cleanup()
+
+	t.Setenv("TFREGBUILDER_TEST_SIGNER_HELPER", "1")
+	helper := func(mode string) []string {
+		return []string{os.Args[0], "-test.run=^TestCommandSignerHelper$", "--", mode}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "command-signer-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filePath := filepath.Join(tmpDir, "SHA256SUMS")
+	if err := os.WriteFile(filePath, []byte("1111  a.zip\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	signer, err := NewCommandSigner(helper("sign"), helper("public-key"), "")
+	if err != nil {
+		t.Fatalf("NewCommandSigner error: %v", err)
+	}
+	keyID, err := ValidateSigner(signer)
+	if err != nil {
+		t.Fatalf("ValidateSigner error: %v", err)
+	}
+	if keyID != os.Getenv("TFREGBUILDER_GPG_ID") {
+		t.Errorf("Key ID = %s, want %s", keyID, os.Getenv("TFREGBUILDER_GPG_ID"))
+	}
+
+	// Armored signatures are stored in the binary form
+	sigPath := filePath + ".sig"
+	if err := SignFile(signer, filePath, sigPath); err != nil {
+		t.Fatalf("SignFile error: %v", err)
+	}
+	signature, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatalf("Failed to read signature: %v", err)
+	}
+	if bytes.Contains(signature, []byte("-----BEGIN PGP SIGNATURE-----")) {
+		t.Errorf("Signature is armored, want binary")
+	}
+	publicKey, err := signer.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey error: %v", err)
+	}
+	if err := VerifySignature(filePath, sigPath, []GPGPublicKey{*publicKey}); err != nil {
+		t.Errorf("VerifySignature error: %v", err)
+	}
+
+	// Failures of the command are reported
+	failing, err := NewCommandSigner(helper("fail"), helper("public-key"), "CUSTOMID")
+	if err != nil {
+		t.Fatalf("NewCommandSigner error: %v", err)
+	}
+	if _, err := ValidateSigner(failing); err == nil {
+		t.Error("ValidateSigner with a failing command succeeded, want error")
+	}
+	// Dry run does not run the signing command
+	if keyID, err := ValidateSignerDryRun(failing); err != nil || keyID != "CUSTOMID" {
+		t.Errorf("ValidateSignerDryRun with a failing command = %s, %v, want CUSTOMID", keyID, err)
+	}
+	if publicKey, err := failing.PublicKey(); err != nil || publicKey.KeyID != "CUSTOMID" {
+		t.Errorf("PublicKey = %+v, %v, want key ID CUSTOMID", publicKey, err)
+	}
+}
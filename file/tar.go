@@ -0,0 +1,143 @@
+// Package file provides utilities for file operations needed by the Terraform registry builder.
+package file
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// tarExcludedDirs lists directory names that are never packaged into module archives.
+var tarExcludedDirs = map[string]bool{
+	".git":       true,
+	".terraform": true,
+}
+
+// CreateTarGzFromDir creates a gzipped tarball of the directory contents with fixed modes and times.
+func CreateTarGzFromDir(srcDir, tarPath string) error {
+	// Create parent directory if it doesn't exist
+	if err := EnsureDir(filepath.Dir(tarPath)); err != nil {
+		return fmt.Errorf("failed to create directory for tarball: %w", err)
+	}
+
+	// Create a new tarball, renamed into place once complete
+	tarFile, err := CreateAtomic(tarPath, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
+	defer tarFile.Abort()
+
+	if err := WriteTarGzFromDir(srcDir, tarFile); err != nil {
+		return err
+	}
+	return tarFile.Commit()
+}
+
+// CalculateTarGzSHA256FromDir calculates the SHA256 hash of the tarball
+// CreateTarGzFromDir would produce for the directory, without writing it.
+func CalculateTarGzSHA256FromDir(srcDir string) (string, error) {
+	hash := sha256.New()
+	if err := WriteTarGzFromDir(srcDir, hash); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// WriteTarGzFromDir writes a gzipped tarball of the directory contents to w.
+// Entries are sorted by path, times are fixed and modes are normalized to 0755 for
+// directories and executables and 0644 for other files, so the output is deterministic.
+// .git and .terraform directories are excluded.
+func WriteTarGzFromDir(srcDir string, w io.Writer) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	// filepath.WalkDir walks in lexical order, which makes the output deterministic
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == srcDir {
+			return nil
+		}
+		if d.IsDir() && tarExcludedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header := &tar.Header{
+			Name:    filepath.ToSlash(relPath),
+			ModTime: time.Unix(0, 0),
+			Format:  tar.FormatPAX,
+		}
+
+		switch {
+		case d.IsDir():
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			header.Mode = 0755
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = target
+			header.Mode = 0777
+		case info.Mode().IsRegular():
+			header.Typeflag = tar.TypeReg
+			header.Size = info.Size()
+			header.Mode = 0644
+			if info.Mode()&0111 != 0 {
+				header.Mode = 0755
+			}
+		default:
+			return fmt.Errorf("unsupported file type: %s", path)
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write tar header: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(tarWriter, f); err != nil {
+				return fmt.Errorf("failed to write %s to tarball: %w", path, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create tarball from %s: %w", srcDir, err)
+	}
+
+	// Finish the tarball
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish tarball: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish gzip: %w", err)
+	}
+
+	return nil
+}
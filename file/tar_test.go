@@ -0,0 +1,91 @@
+package file
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateTarGzFromDir(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "tar_test")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	files := map[string]string{
+		"main.tf":                        "resource {}",
+		filepath.Join("sub", "x.tf"):     "variable {}",
+		filepath.Join(".git", "HEAD"):    "ref: refs/heads/main",
+		filepath.Join(".terraform", "x"): "cache",
+	}
+	for name, content := range files {
+		path := filepath.Join(srcDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	tarPath := filepath.Join(tmpDir, "out", "module.tar.gz")
+	if err := CreateTarGzFromDir(srcDir, tarPath); err != nil {
+		t.Fatalf("CreateTarGzFromDir() error = %v", err)
+	}
+
+	// Inspect the tarball
+	f, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatalf("Failed to open tarball: %v", err)
+	}
+	defer f.Close()
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Failed to open gzip: %v", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	var names []string
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read tarball: %v", err)
+		}
+		names = append(names, header.Name)
+		if header.Typeflag == tar.TypeReg && header.Mode != 0644 {
+			t.Errorf("Mode of %s = %o, want 644", header.Name, header.Mode)
+		}
+	}
+
+	expected := []string{"main.tf", "sub/", "sub/x.tf"}
+	if len(names) != len(expected) {
+		t.Fatalf("Tarball entries = %v, want %v", names, expected)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Tarball entry %d = %s, want %s", i, names[i], expected[i])
+		}
+	}
+
+	// The hash must match the tarball without writing it, i.e., the output is deterministic
+	want, err := CalculateSHA256(tarPath)
+	if err != nil {
+		t.Fatalf("CalculateSHA256() error = %v", err)
+	}
+	got, err := CalculateTarGzSHA256FromDir(srcDir)
+	if err != nil {
+		t.Fatalf("CalculateTarGzSHA256FromDir() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("CalculateTarGzSHA256FromDir() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,289 @@
+package file
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// transactionSuffix is the suffix of the names of the staging directories keeping the previous states.
+const transactionSuffix = ".rollback-"
+
+// Transaction keeps the previous state of directories so that the changes to them can be rolled back.
+// The files are kept in a staging directory next to each directory, outside the tree served from it,
+// with hard links where supported so that beginning a transaction copies no content.
+// Files in the directories must be replaced by renames, as the functions of this package do,
+// rather than rewritten in place.
+type Transaction struct {
+	snapshots []snapshot
+}
+
+// snapshot is the previous state of a directory changed in a transaction.
+type snapshot struct {
+	dir string
+	// staging is the directory keeping the previous content, empty if dir did not exist.
+	staging string
+}
+
+// BeginTransaction records the current state of the directories to roll back the changes to them.
+func BeginTransaction(dirs ...string) (*Transaction, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+
+	t := &Transaction{}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+		}
+		s := snapshot{dir: abs}
+		if _, err := os.Stat(abs); err == nil {
+			s.staging = stagingPrefix(abs) + hex.EncodeToString(id)
+			if err := linkTree(abs, s.staging); err != nil {
+				os.RemoveAll(s.staging)
+				t.removeStaging()
+				return nil, err
+			}
+		} else if !os.IsNotExist(err) {
+			t.removeStaging()
+			return nil, fmt.Errorf("failed to check %s: %w", dir, err)
+		}
+		t.snapshots = append(t.snapshots, s)
+	}
+	return t, nil
+}
+
+// Commit ends the transaction, keeping the changes and removing the previous states.
+func (t *Transaction) Commit() error {
+	return t.removeStaging()
+}
+
+// Rollback ends the transaction, restoring the directories to their previous state.
+// Returns the descriptions of the restored paths.
+// Rollback continues on failures and returns the first one, keeping the previous states for manual recovery.
+func (t *Transaction) Rollback() ([]string, error) {
+	var rolledBack []string
+	var firstErr error
+	for _, s := range t.snapshots {
+		restored, err := s.restore()
+		rolledBack = append(rolledBack, restored...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return rolledBack, firstErr
+	}
+	return rolledBack, t.removeStaging()
+}
+
+// removeStaging removes the staging directories of the transaction.
+func (t *Transaction) removeStaging() error {
+	for _, s := range t.snapshots {
+		if s.staging == "" {
+			continue
+		}
+		if err := os.RemoveAll(s.staging); err != nil {
+			return fmt.Errorf("failed to remove previous state of %s: %w", s.dir, err)
+		}
+	}
+	return nil
+}
+
+// restore restores the directory to the state kept in the staging directory.
+// Paths not existing before are removed first, then paths replaced or removed are moved back.
+func (s snapshot) restore() ([]string, error) {
+	if s.staging == "" {
+		if _, err := os.Lstat(s.dir); os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err := os.RemoveAll(s.dir); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", s.dir, err)
+		}
+		return []string{"removed " + s.dir}, nil
+	}
+
+	var rolledBack []string
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil || rel == "." || rel == LockFileName {
+			return err
+		}
+		prev, err := os.Lstat(filepath.Join(s.staging, rel))
+		if err == nil && prev.IsDir() == d.IsDir() {
+			return nil
+		} else if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		rolledBack = append(rolledBack, "removed "+path)
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return rolledBack, err
+	}
+
+	err = filepath.WalkDir(s.staging, func(prevPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.staging, prevPath)
+		if err != nil || rel == "." {
+			return err
+		}
+		path := filepath.Join(s.dir, rel)
+		if _, err := os.Lstat(path); err == nil {
+			if d.IsDir() {
+				return nil
+			}
+			same, err := sameFile(path, prevPath)
+			if err != nil || same {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Rename(prevPath, path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		rolledBack = append(rolledBack, "restored "+path)
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// RemoveStaleTransactions removes the previous states of the directories left by transactions
+// that were neither committed nor rolled back, e.g., of a process killed during a build.
+// Returns the removed staging directories.
+func RemoveStaleTransactions(dirs ...string) ([]string, error) {
+	var removed []string
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return removed, fmt.Errorf("failed to resolve %s: %w", dir, err)
+		}
+		prefix := stagingPrefix(abs)
+		entries, err := os.ReadDir(filepath.Dir(abs))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("failed to read directory %s: %w", filepath.Dir(abs), err)
+		}
+		for _, entry := range entries {
+			staging := filepath.Join(filepath.Dir(abs), entry.Name())
+			if !entry.IsDir() || !strings.HasPrefix(staging, prefix) {
+				continue
+			}
+			if err := os.RemoveAll(staging); err != nil {
+				return removed, fmt.Errorf("failed to remove %s: %w", staging, err)
+			}
+			removed = append(removed, staging)
+		}
+	}
+	return removed, nil
+}
+
+// stagingPrefix returns the path of the staging directories of the directory without the transaction ID.
+func stagingPrefix(dir string) string {
+	return filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+transactionSuffix)
+}
+
+// linkTree recreates the directory tree of src at dst, linking the files.
+// Files are copied where hard links are not supported. The lock file of src is skipped.
+func linkTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == LockFileName {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if err := os.Mkdir(target, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to keep previous state of %s: %w", path, err)
+			}
+			return nil
+		}
+		if err := os.Link(path, target); err != nil {
+			if err := copyPreviousFile(path, target, info); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// copyPreviousFile copies the file to keep its previous state, keeping its mode and modification time.
+func copyPreviousFile(path, target string, info fs.FileInfo) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to keep previous state of %s: %w", path, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to keep previous state of %s: %w", path, err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to keep previous state of %s: %w", path, err)
+	}
+	return os.Chtimes(target, info.ModTime(), info.ModTime())
+}
+
+// sameFile returns whether the files are the same, either linked or with the same content.
+func sameFile(path1, path2 string) (bool, error) {
+	info1, err := os.Lstat(path1)
+	if err != nil {
+		return false, err
+	}
+	info2, err := os.Lstat(path2)
+	if err != nil {
+		return false, err
+	}
+	if os.SameFile(info1, info2) {
+		return true, nil
+	}
+	if !info1.Mode().IsRegular() || !info2.Mode().IsRegular() || info1.Size() != info2.Size() {
+		return false, nil
+	}
+
+	data1, err := os.ReadFile(path1)
+	if err != nil {
+		return false, err
+	}
+	data2, err := os.ReadFile(path2)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(data1, data2), nil
+}
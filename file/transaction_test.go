@@ -0,0 +1,176 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setupTransactionDir creates a directory with a file and a sub-directory to be changed in a transaction.
+func setupTransactionDir(t *testing.T) string {
+	t.Helper()
+
+	tmpDir, err := os.MkdirTemp("", "transaction-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "index.json"), []byte("old index"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "package"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "package", "binary"), []byte("old binary"), 0755); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	return tmpDir
+}
+
+// changeTransactionDir replaces, adds and removes files in the directory.
+func changeTransactionDir(t *testing.T, dir string) {
+	t.Helper()
+
+	if err := WriteFileAtomic(filepath.Join(dir, "index.json"), []byte("new index"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	if err := EnsureDir(filepath.Join(dir, "1.0.0", "download")); err != nil {
+		t.Fatalf("EnsureDir() error = %v", err)
+	}
+	if err := WriteFileAtomic(filepath.Join(dir, "1.0.0", "download", "test.zip"), []byte("zip"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "package")); err != nil {
+		t.Fatalf("Failed to remove directory: %v", err)
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	dir := setupTransactionDir(t)
+
+	tx, err := BeginTransaction(dir)
+	if err != nil {
+		t.Fatalf("BeginTransaction() error = %v", err)
+	}
+	// The previous state is kept outside the directory
+	assertNoTemporaryFiles(t, dir, 2)
+	changeTransactionDir(t, dir)
+
+	rolledBack, err := tx.Rollback()
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	want := []string{
+		"removed " + filepath.Join(dir, "1.0.0"),
+		"restored " + filepath.Join(dir, "index.json"),
+		"restored " + filepath.Join(dir, "package"),
+	}
+	if !reflect.DeepEqual(rolledBack, want) {
+		t.Errorf("Rollback() = %v, want %v", rolledBack, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil || string(data) != "old index" {
+		t.Errorf("index.json = %q, %v, want %q", data, err, "old index")
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "package", "binary"))
+	if err != nil || string(data) != "old binary" {
+		t.Errorf("package/binary = %q, %v, want %q", data, err, "old binary")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("Created directory is not removed: %v", err)
+	}
+	assertNoTemporaryFiles(t, dir, 2)
+	assertNoStaging(t, dir)
+}
+
+func TestTransactionCommit(t *testing.T) {
+	dir := setupTransactionDir(t)
+
+	tx, err := BeginTransaction(dir)
+	if err != nil {
+		t.Fatalf("BeginTransaction() error = %v", err)
+	}
+	changeTransactionDir(t, dir)
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil || string(data) != "new index" {
+		t.Errorf("index.json = %q, %v, want %q", data, err, "new index")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "package")); !os.IsNotExist(err) {
+		t.Errorf("Removed directory exists: %v", err)
+	}
+	assertNoTemporaryFiles(t, dir, 2)
+	assertNoStaging(t, dir)
+}
+
+// TestTransactionRollbackNewDir verifies that a directory created in a transaction is removed on rollback.
+func TestTransactionRollbackNewDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mirror")
+
+	tx, err := BeginTransaction(dir)
+	if err != nil {
+		t.Fatalf("BeginTransaction() error = %v", err)
+	}
+	if err := EnsureDir(filepath.Join(dir, "registry.example.com")); err != nil {
+		t.Fatalf("EnsureDir() error = %v", err)
+	}
+
+	rolledBack, err := tx.Rollback()
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if want := []string{"removed " + dir}; !reflect.DeepEqual(rolledBack, want) {
+		t.Errorf("Rollback() = %v, want %v", rolledBack, want)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Created directory is not removed: %v", err)
+	}
+}
+
+func TestRemoveStaleTransactions(t *testing.T) {
+	dir := setupTransactionDir(t)
+
+	// A transaction neither committed nor rolled back, as by a killed process
+	if _, err := BeginTransaction(dir); err != nil {
+		t.Fatalf("BeginTransaction() error = %v", err)
+	}
+	changeTransactionDir(t, dir)
+
+	removed, err := RemoveStaleTransactions(dir)
+	if err != nil {
+		t.Fatalf("RemoveStaleTransactions() error = %v", err)
+	}
+	if len(removed) != 1 || !strings.HasPrefix(filepath.Base(removed[0]), "."+filepath.Base(dir)+".rollback-") {
+		t.Errorf("RemoveStaleTransactions() = %v, want the staging directory", removed)
+	}
+	assertNoStaging(t, dir)
+
+	// The directory is left as it is
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil || string(data) != "new index" {
+		t.Errorf("index.json = %q, %v, want %q", data, err, "new index")
+	}
+}
+
+// assertNoStaging verifies that no staging directory of a transaction is left next to the directory.
+func assertNoStaging(t *testing.T, dir string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "."+filepath.Base(dir)+".rollback-") {
+			t.Errorf("Staging directory %s is left", entry.Name())
+		}
+	}
+}
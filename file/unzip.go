@@ -0,0 +1,72 @@
+package file
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractZip extracts a zip file into a directory, keeping the executable bit of the files.
+// Entries escaping the directory are rejected.
+func ExtractZip(zipPath, dstDir string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer r.Close()
+
+	if err := EnsureDir(dstDir); err != nil {
+		return err
+	}
+
+	for _, f := range r.File {
+		name := filepath.FromSlash(f.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid file name in zip file: %q", f.Name)
+		}
+		path := filepath.Join(dstDir, name)
+
+		if f.FileInfo().IsDir() {
+			if err := EnsureDir(path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := EnsureDir(filepath.Dir(path)); err != nil {
+			return err
+		}
+		if err := extractZipEntry(f, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractZipEntry writes a file in a zip file to the path.
+func extractZipEntry(f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s in zip file: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	mode := os.FileMode(0644)
+	if f.Mode()&0111 != 0 || strings.HasSuffix(f.Name, ".exe") {
+		mode = 0755
+	}
+
+	out, err := CreateAtomic(path, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+
+	if _, err := io.Copy(out, rc); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+	}
+	return out.Commit()
+}
@@ -0,0 +1,79 @@
+package file
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractZip(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "unzip-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	binaryPath := filepath.Join(tmpDir, "terraform-provider-test_v1.0.0_linux_amd64")
+	if err := os.WriteFile(binaryPath, []byte("mock binary content"), 0755); err != nil {
+		t.Fatalf("Failed to create binary file: %v", err)
+	}
+	zipPath := filepath.Join(tmpDir, "test.zip")
+	if err := CreateZipFromBinary(binaryPath, zipPath); err != nil {
+		t.Fatalf("CreateZipFromBinary() error = %v", err)
+	}
+
+	extractDir := filepath.Join(tmpDir, "extracted")
+	if err := ExtractZip(zipPath, extractDir); err != nil {
+		t.Fatalf("ExtractZip() error = %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(extractDir, "terraform-provider-test_v1.0.0"))
+	if err != nil {
+		t.Fatalf("Extracted file not found: %v", err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Extracted file mode = %v, want executable", info.Mode())
+	}
+
+	zipHash, err := CalculateZipH1Hash(zipPath)
+	if err != nil {
+		t.Fatalf("CalculateZipH1Hash() error = %v", err)
+	}
+	dirHash, err := CalculateDirH1Hash(extractDir)
+	if err != nil {
+		t.Fatalf("CalculateDirH1Hash() error = %v", err)
+	}
+	if dirHash != zipHash {
+		t.Errorf("CalculateDirH1Hash() = %v, want %v", dirHash, zipHash)
+	}
+}
+
+func TestExtractZipRejectsEscapingEntries(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "unzip-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	zipPath := filepath.Join(tmpDir, "evil.zip")
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to create zip file: %v", err)
+	}
+	zipWriter := zip.NewWriter(zipFile)
+	if _, err := zipWriter.Create("../evil"); err != nil {
+		t.Fatalf("Failed to create zip entry: %v", err)
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+	zipFile.Close()
+
+	if err := ExtractZip(zipPath, filepath.Join(tmpDir, "extracted")); err == nil {
+		t.Error("ExtractZip() error = nil, want error")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "evil")); !os.IsNotExist(err) {
+		t.Error("Entry escaping the directory was extracted")
+	}
+}
@@ -0,0 +1,145 @@
+// Package goreleaser provides types and utilities for reading the dist directory produced by goreleaser.
+package goreleaser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File names of the metadata goreleaser writes to the dist directory.
+const (
+	ArtifactsFileName = "artifacts.json"
+	MetadataFileName  = "metadata.json"
+)
+
+// Artifact types used by goreleaser in artifacts.json.
+const (
+	TypeArchive        = "Archive"
+	TypeChecksum       = "Checksum"
+	TypeSignature      = "Signature"
+	TypeUploadableFile = "Uploadable File"
+)
+
+// Artifact represents an entry in artifacts.json.
+type Artifact struct {
+	Name   string                 `json:"name"`
+	Path   string                 `json:"path"`
+	Goos   string                 `json:"goos,omitempty"`
+	Goarch string                 `json:"goarch,omitempty"`
+	Type   string                 `json:"type"`
+	Extra  map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Format returns the archive format recorded in the extra fields, e.g., "zip".
+func (a *Artifact) Format() string {
+	format, _ := a.Extra["Format"].(string)
+	return format
+}
+
+// IsZipArchive returns whether the artifact is a zip archive.
+func (a *Artifact) IsZipArchive() bool {
+	if a.Type != TypeArchive {
+		return false
+	}
+	if format := a.Format(); format != "" {
+		return format == "zip"
+	}
+	return strings.HasSuffix(a.Name, ".zip")
+}
+
+// IsManifest returns whether the artifact is a terraform registry manifest.
+func (a *Artifact) IsManifest() bool {
+	return strings.HasSuffix(a.Name, "_manifest.json")
+}
+
+// Metadata represents the content of metadata.json.
+type Metadata struct {
+	ProjectName string `json:"project_name"`
+	Tag         string `json:"tag"`
+	Version     string `json:"version"`
+}
+
+// Dist represents a goreleaser dist directory.
+type Dist struct {
+	Dir       string
+	Metadata  Metadata
+	Artifacts []Artifact
+}
+
+// ReadDist reads artifacts.json and metadata.json in the dist directory.
+func ReadDist(distDir string) (*Dist, error) {
+	dist := &Dist{Dir: distDir}
+
+	data, err := os.ReadFile(filepath.Join(distDir, MetadataFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read goreleaser metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &dist.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse goreleaser metadata: %w", err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(distDir, ArtifactsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read goreleaser artifacts: %w", err)
+	}
+	if err := json.Unmarshal(data, &dist.Artifacts); err != nil {
+		return nil, fmt.Errorf("failed to parse goreleaser artifacts: %w", err)
+	}
+
+	return dist, nil
+}
+
+// ProviderType returns the provider type derived from the project name, e.g., "aws" for "terraform-provider-aws".
+func (d *Dist) ProviderType() (string, error) {
+	providerType, ok := strings.CutPrefix(d.Metadata.ProjectName, "terraform-provider-")
+	if !ok || providerType == "" {
+		return "", fmt.Errorf("goreleaser project name is not a terraform provider: %q", d.Metadata.ProjectName)
+	}
+	return providerType, nil
+}
+
+// ProviderVersion returns the version of the release without the "v" prefix.
+func (d *Dist) ProviderVersion() (string, error) {
+	version := d.Metadata.Version
+	if version == "" {
+		version = d.Metadata.Tag
+	}
+	version = strings.TrimPrefix(version, "v")
+	if version == "" {
+		return "", fmt.Errorf("goreleaser metadata has no version")
+	}
+	return version, nil
+}
+
+// ArtifactPath resolves the path of an artifact.
+// Paths in artifacts.json are relative to the project directory, which is usually
+// the parent of the dist directory; the artifact is looked up in the dist directory otherwise.
+func (d *Dist) ArtifactPath(artifact *Artifact) string {
+	candidates := []string{filepath.Join(d.Dir, artifact.Name)}
+	if filepath.IsAbs(artifact.Path) {
+		candidates = append([]string{artifact.Path}, candidates...)
+	} else if artifact.Path != "" {
+		candidates = append([]string{filepath.Join(filepath.Dir(d.Dir), artifact.Path)}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// FindArtifacts returns the artifacts matching the filter.
+func (d *Dist) FindArtifacts(filter func(*Artifact) bool) []*Artifact {
+	var artifacts []*Artifact
+	for i := range d.Artifacts {
+		if filter(&d.Artifacts[i]) {
+			artifacts = append(artifacts, &d.Artifacts[i])
+		}
+	}
+	return artifacts
+}
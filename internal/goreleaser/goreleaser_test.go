@@ -0,0 +1,82 @@
+package goreleaser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadDist(t *testing.T) {
+	projectDir := t.TempDir()
+	distDir := filepath.Join(projectDir, "dist")
+	if err := os.MkdirAll(distDir, 0755); err != nil {
+		t.Fatalf("Failed to create dist directory: %v", err)
+	}
+
+	files := map[string]string{
+		MetadataFileName: `{"project_name": "terraform-provider-example", "tag": "v1.2.3", "version": "1.2.3"}`,
+		ArtifactsFileName: `[
+			{"name": "terraform-provider-example_1.2.3_linux_amd64.zip", "path": "dist/terraform-provider-example_1.2.3_linux_amd64.zip", "goos": "linux", "goarch": "amd64", "type": "Archive", "extra": {"Format": "zip"}},
+			{"name": "terraform-provider-example_1.2.3_linux_amd64.tar.gz", "path": "dist/terraform-provider-example_1.2.3_linux_amd64.tar.gz", "goos": "linux", "goarch": "amd64", "type": "Archive", "extra": {"Format": "tar.gz"}},
+			{"name": "terraform-provider-example_1.2.3_SHA256SUMS", "path": "/nonexistent/terraform-provider-example_1.2.3_SHA256SUMS", "type": "Checksum"},
+			{"name": "terraform-provider-example_1.2.3_manifest.json", "path": "dist/terraform-provider-example_1.2.3_manifest.json", "type": "Uploadable File"}
+		]`,
+		"terraform-provider-example_1.2.3_linux_amd64.zip": "zip",
+		"terraform-provider-example_1.2.3_SHA256SUMS":      "sums",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(distDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	dist, err := ReadDist(distDir)
+	if err != nil {
+		t.Fatalf("ReadDist() error = %v", err)
+	}
+
+	providerType, err := dist.ProviderType()
+	if err != nil || providerType != "example" {
+		t.Errorf("ProviderType() = %q, %v, want example", providerType, err)
+	}
+	version, err := dist.ProviderVersion()
+	if err != nil || version != "1.2.3" {
+		t.Errorf("ProviderVersion() = %q, %v, want 1.2.3", version, err)
+	}
+
+	archives := dist.FindArtifacts(func(a *Artifact) bool { return a.IsZipArchive() })
+	if len(archives) != 1 {
+		t.Fatalf("Found %d zip archives, want 1", len(archives))
+	}
+	// Relative paths are resolved against the project directory
+	if got, want := dist.ArtifactPath(archives[0]), filepath.Join(distDir, "terraform-provider-example_1.2.3_linux_amd64.zip"); got != want {
+		t.Errorf("ArtifactPath() = %q, want %q", got, want)
+	}
+
+	checksums := dist.FindArtifacts(func(a *Artifact) bool { return a.Type == TypeChecksum })
+	if len(checksums) != 1 {
+		t.Fatalf("Found %d checksums, want 1", len(checksums))
+	}
+	// Paths that don't exist fall back to the dist directory
+	if got, want := dist.ArtifactPath(checksums[0]), filepath.Join(distDir, "terraform-provider-example_1.2.3_SHA256SUMS"); got != want {
+		t.Errorf("ArtifactPath() = %q, want %q", got, want)
+	}
+
+	manifests := dist.FindArtifacts(func(a *Artifact) bool { return a.IsManifest() })
+	if len(manifests) != 1 {
+		t.Errorf("Found %d manifests, want 1", len(manifests))
+	}
+}
+
+func TestDistProviderType(t *testing.T) {
+	dist := &Dist{Metadata: Metadata{ProjectName: "not-a-provider", Version: "1.0.0"}}
+	if _, err := dist.ProviderType(); err == nil {
+		t.Error("ProviderType() error = nil, want error")
+	}
+
+	// The version falls back to the tag
+	dist = &Dist{Metadata: Metadata{ProjectName: "terraform-provider-x", Tag: "v2.0.0"}}
+	if version, err := dist.ProviderVersion(); err != nil || version != "2.0.0" {
+		t.Errorf("ProviderVersion() = %q, %v, want 2.0.0", version, err)
+	}
+}
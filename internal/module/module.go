@@ -0,0 +1,100 @@
+// Package module provides types and utilities for parsing and managing Terraform module information.
+package module
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
+)
+
+// ModuleInfo represents the parsed information from a module source name.
+type ModuleInfo struct {
+	Namespace string // Registry namespace, e.g., "hashicorp"
+	Name      string // Module name, e.g., "consul"
+	System    string // Target system, e.g., "aws"
+	Version   string // Module version, e.g., "0.1.0"
+}
+
+// ArchiveExt is the extension of module archives.
+const ArchiveExt = ".tar.gz"
+
+var (
+	// Regular expression to match module source names.
+	// Format: (NAMESPACE)-(NAME)-(SYSTEM)_v(VERSION) for directories or
+	// (NAMESPACE)-(NAME)-(SYSTEM)_v(VERSION).tar.gz for tarballs.
+	// Only NAME may contain hyphens.
+	moduleRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_]*)-([A-Za-z0-9][A-Za-z0-9_-]*)-([A-Za-z0-9]+)_v([0-9][^/\\]*)$`)
+)
+
+// ParseModuleName parses a module source directory or tarball name and returns the module information.
+func ParseModuleName(filename string) (*ModuleInfo, error) {
+	// Extract just the base name
+	baseName := strings.TrimSuffix(filepath.Base(filename), ArchiveExt)
+
+	// Match against the regex
+	matches := moduleRegex.FindStringSubmatch(baseName)
+	if matches == nil || len(matches) != 5 {
+		return nil, fmt.Errorf("invalid module name format: %s", filename)
+	}
+
+	// Versions must follow Semantic Versioning so that they can be ordered
+	if _, err := semver.Parse(matches[4]); err != nil {
+		return nil, fmt.Errorf("invalid module version in name %s: %w", filename, err)
+	}
+
+	return &ModuleInfo{
+		Namespace: matches[1],
+		Name:      matches[2],
+		System:    matches[3],
+		Version:   matches[4],
+	}, nil
+}
+
+// IsModuleName returns whether the name is a valid module source directory or tarball name.
+func IsModuleName(filename string) bool {
+	_, err := ParseModuleName(filename)
+	return err == nil
+}
+
+// IsArchive returns whether the module source is a tarball.
+func (m *ModuleInfo) IsArchive(filename string) bool {
+	return strings.HasSuffix(filename, ArchiveExt)
+}
+
+// FullName returns the module address without the hostname, e.g., "hashicorp/consul/aws".
+func (m *ModuleInfo) FullName() string {
+	return m.Namespace + "/" + m.Name + "/" + m.System
+}
+
+// TargetBasePath returns the base path for this module in the registry structure.
+func (m *ModuleInfo) TargetBasePath() string {
+	return filepath.Join(m.Namespace, m.Name, m.System)
+}
+
+// TargetVersionPath returns the version-specific path for this module in the registry structure.
+func (m *ModuleInfo) TargetVersionPath() string {
+	return filepath.Join(m.TargetBasePath(), m.Version)
+}
+
+// TargetVersionsIndexPath returns the path to the versions index file.
+func (m *ModuleInfo) TargetVersionsIndexPath() string {
+	return filepath.Join(m.TargetBasePath(), "versions", "index.json")
+}
+
+// TargetDownloadIndexPath returns the path to the download index file.
+func (m *ModuleInfo) TargetDownloadIndexPath() string {
+	return filepath.Join(m.TargetVersionPath(), "download", "index.json")
+}
+
+// TargetArchiveFileName returns the name of the target tarball.
+func (m *ModuleInfo) TargetArchiveFileName() string {
+	return fmt.Sprintf("%s-%s-%s_v%s%s", m.Namespace, m.Name, m.System, m.Version, ArchiveExt)
+}
+
+// TargetArchivePath returns the full path to the target tarball.
+func (m *ModuleInfo) TargetArchivePath() string {
+	return filepath.Join(m.TargetVersionPath(), m.TargetArchiveFileName())
+}
@@ -0,0 +1,124 @@
+package module
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseModuleName(t *testing.T) {
+	tests := []struct {
+		name          string
+		filename      string
+		wantNamespace string
+		wantName      string
+		wantSystem    string
+		wantVersion   string
+		wantErr       bool
+	}{
+		{
+			name:          "tarball",
+			filename:      "hashicorp-consul-aws_v1.2.3.tar.gz",
+			wantNamespace: "hashicorp",
+			wantName:      "consul",
+			wantSystem:    "aws",
+			wantVersion:   "1.2.3",
+		},
+		{
+			name:          "directory",
+			filename:      "/src/modules/team-vpc-google_v0.1.0",
+			wantNamespace: "team",
+			wantName:      "vpc",
+			wantSystem:    "google",
+			wantVersion:   "0.1.0",
+		},
+		{
+			name:          "name with hyphens",
+			filename:      "example-network-peering-azurerm_v2.0.0-beta.1.tar.gz",
+			wantNamespace: "example",
+			wantName:      "network-peering",
+			wantSystem:    "azurerm",
+			wantVersion:   "2.0.0-beta.1",
+		},
+		{
+			name:     "provider file name",
+			filename: "terraform-provider-aws_v1.0.0_linux_amd64",
+			wantErr:  true,
+		},
+		{
+			name:     "missing system",
+			filename: "hashicorp-consul_v1.0.0.tar.gz",
+			wantErr:  true,
+		},
+		{
+			name:     "non semantic version",
+			filename: "hashicorp-consul-aws_v1.0.tar.gz",
+			wantErr:  true,
+		},
+		{
+			name:     "missing version",
+			filename: "hashicorp-consul-aws.tar.gz",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseModuleName(tt.filename)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseModuleName() error = nil, wantErr = true")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseModuleName() error = %v, wantErr = false", err)
+			}
+
+			if got.Namespace != tt.wantNamespace {
+				t.Errorf("Namespace = %v, want %v", got.Namespace, tt.wantNamespace)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("Name = %v, want %v", got.Name, tt.wantName)
+			}
+			if got.System != tt.wantSystem {
+				t.Errorf("System = %v, want %v", got.System, tt.wantSystem)
+			}
+			if got.Version != tt.wantVersion {
+				t.Errorf("Version = %v, want %v", got.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestModuleInfo_Paths(t *testing.T) {
+	info := ModuleInfo{
+		Namespace: "example",
+		Name:      "vpc",
+		System:    "aws",
+		Version:   "1.0.0",
+	}
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"FullName", info.FullName(), "example/vpc/aws"},
+		{"TargetBasePath", info.TargetBasePath(), filepath.Join("example", "vpc", "aws")},
+		{"TargetVersionPath", info.TargetVersionPath(), filepath.Join("example", "vpc", "aws", "1.0.0")},
+		{"TargetVersionsIndexPath", info.TargetVersionsIndexPath(), filepath.Join("example", "vpc", "aws", "versions", "index.json")},
+		{"TargetDownloadIndexPath", info.TargetDownloadIndexPath(), filepath.Join("example", "vpc", "aws", "1.0.0", "download", "index.json")},
+		{"TargetArchiveFileName", info.TargetArchiveFileName(), "example-vpc-aws_v1.0.0.tar.gz"},
+		{"TargetArchivePath", info.TargetArchivePath(), filepath.Join("example", "vpc", "aws", "1.0.0", "example-vpc-aws_v1.0.0.tar.gz")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("%s() = %v, want %v", tt.name, tt.got, tt.expected)
+			}
+		})
+	}
+}
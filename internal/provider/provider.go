@@ -6,18 +6,24 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
 )
 
 // ProviderInfo represents the parsed information from a provider file name.
 type ProviderInfo struct {
-	Type    string // Provider type, e.g., "aws"
-	Version string // Provider version, e.g., "0.1.0"
-	OS      string // Operating system, e.g., "linux"
-	Arch    string // Architecture, e.g., "amd64"
-	Ext     string // Extension for the binary (e.g., ".exe" for Windows)
+	Namespace string // Registry namespace, e.g., "hashicorp"; empty when the registry root is the namespace directory
+	Type      string // Provider type, e.g., "aws"
+	Version   string // Provider version, e.g., "0.1.0"
+	OS        string // Operating system, e.g., "linux"
+	Arch      string // Architecture, e.g., "amd64"
+	Ext       string // Extension for the binary (e.g., ".exe" for Windows)
 }
 
 var (
+	// Regular expression to match namespaces.
+	namespaceRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
 	// Regular expression to match provider file names.
 	// Format: terraform-provider-(TYPE)_v(VERSION)_(OS)_(ARCH)[.exe] or
 	// terraform-provider-(TYPE)_v(VERSION)_(OS)_(ARCH).zip
@@ -42,6 +48,11 @@ func ParseProviderFileName(filename string) (*ProviderInfo, error) {
 		ext = matches[5] // This will be ".exe" or empty string
 	}
 
+	// Versions must follow Semantic Versioning so that they can be ordered
+	if _, err := semver.Parse(matches[2]); err != nil {
+		return nil, fmt.Errorf("invalid provider version in file name %s: %w", filename, err)
+	}
+
 	return &ProviderInfo{
 		Type:    matches[1],
 		Version: matches[2],
@@ -51,24 +62,46 @@ func ParseProviderFileName(filename string) (*ProviderInfo, error) {
 	}, nil
 }
 
+// ValidateNamespace checks that a namespace can be used as a registry namespace and a directory name.
+func ValidateNamespace(namespace string) error {
+	if !namespaceRegex.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace: %q", namespace)
+	}
+	return nil
+}
+
+// FullName returns the provider type qualified with the namespace if it is set, e.g., "hashicorp/aws".
+func (p *ProviderInfo) FullName() string {
+	if p.Namespace == "" {
+		return p.Type
+	}
+	return p.Namespace + "/" + p.Type
+}
+
 // TargetBasePath returns the base path for this provider in the registry structure.
+// The path includes the namespace if it is set.
 func (p *ProviderInfo) TargetBasePath() string {
-	return p.Type
+	return filepath.Join(p.Namespace, p.Type)
 }
 
 // TargetVersionPath returns the version-specific path for this provider in the registry structure.
 func (p *ProviderInfo) TargetVersionPath() string {
-	return filepath.Join(p.Type, p.Version)
+	return filepath.Join(p.TargetBasePath(), p.Version)
 }
 
 // TargetDownloadPath returns the download path for this provider in the registry structure.
 func (p *ProviderInfo) TargetDownloadPath() string {
-	return filepath.Join(p.Type, p.Version, "download", p.OS, p.Arch)
+	return filepath.Join(p.TargetVersionPath(), "download", p.OS, p.Arch)
+}
+
+// TargetVersionsPath returns the path to the versions directory.
+func (p *ProviderInfo) TargetVersionsPath() string {
+	return filepath.Join(p.TargetBasePath(), "versions")
 }
 
 // TargetVersionsIndexPath returns the path to the versions index file.
 func (p *ProviderInfo) TargetVersionsIndexPath() string {
-	return filepath.Join(p.Type, "versions", "index.json")
+	return filepath.Join(p.TargetVersionsPath(), "index.json")
 }
 
 // TargetDownloadIndexPath returns the path to the download index file.
@@ -81,6 +114,12 @@ func (p *ProviderInfo) TargetZipFileName() string {
 	return fmt.Sprintf("terraform-provider-%s_v%s_%s_%s.zip", p.Type, p.Version, p.OS, p.Arch)
 }
 
+// TargetUpstreamZipFileName returns the name of the zip file in the releases made by goreleaser
+// and in the public registry, e.g., "terraform-provider-example_1.0.0_linux_amd64.zip".
+func (p *ProviderInfo) TargetUpstreamZipFileName() string {
+	return fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", p.Type, p.Version, p.OS, p.Arch)
+}
+
 // TargetZipPath returns the full path to the target zip file.
 func (p *ProviderInfo) TargetZipPath() string {
 	return filepath.Join(p.TargetDownloadPath(), p.TargetZipFileName())
@@ -106,11 +145,64 @@ func (p *ProviderInfo) TargetSigPath() string {
 	return filepath.Join(p.TargetDownloadPath(), p.TargetSigFileName())
 }
 
+// TargetH1FileName returns the name of the file recording the "h1:" hash of the zip file.
+func (p *ProviderInfo) TargetH1FileName() string {
+	return p.TargetZipFileName() + ".h1"
+}
+
+// TargetH1Path returns the full path to the file recording the "h1:" hash of the zip file.
+func (p *ProviderInfo) TargetH1Path() string {
+	return filepath.Join(p.TargetDownloadPath(), p.TargetH1FileName())
+}
+
+// TargetVersionSHASumsFileName returns the name of the SHA sums file covering all platforms of the version.
+// The name follows goreleaser, e.g., "terraform-provider-example_1.0.0_SHA256SUMS".
+func (p *ProviderInfo) TargetVersionSHASumsFileName() string {
+	return fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", p.Type, p.Version)
+}
+
+// TargetVersionSHASumsPath returns the full path to the SHA sums file covering all platforms of the version.
+func (p *ProviderInfo) TargetVersionSHASumsPath() string {
+	return filepath.Join(p.TargetVersionPath(), "download", p.TargetVersionSHASumsFileName())
+}
+
+// TargetVersionSigFileName returns the name of the signature file of the SHA sums file covering all platforms.
+func (p *ProviderInfo) TargetVersionSigFileName() string {
+	return p.TargetVersionSHASumsFileName() + ".sig"
+}
+
+// TargetVersionSigPath returns the full path to the signature file of the SHA sums file covering all platforms.
+func (p *ProviderInfo) TargetVersionSigPath() string {
+	return filepath.Join(p.TargetVersionPath(), "download", p.TargetVersionSigFileName())
+}
+
+// TargetManifestFileName returns the name of the registry manifest file listed in the SHA256SUMS file
+// covering all platforms, e.g., "terraform-provider-example_1.0.0_manifest.json" as goreleaser names it.
+func (p *ProviderInfo) TargetManifestFileName() string {
+	return fmt.Sprintf("terraform-provider-%s_%s_manifest.json", p.Type, p.Version)
+}
+
+// TargetManifestPath returns the full path to the registry manifest file listed in the SHA256SUMS file
+// covering all platforms.
+func (p *ProviderInfo) TargetManifestPath() string {
+	return filepath.Join(p.TargetVersionPath(), "download", p.TargetManifestFileName())
+}
+
 // IsZipFile returns whether the original file is a zip file.
 func (p *ProviderInfo) IsZipFile(filename string) bool {
 	return strings.HasSuffix(filename, ".zip")
 }
 
+// ManifestFileNames returns the names of registry manifest files that may accompany
+// the provider file, in order of preference.
+// The first one is the name used by goreleaser.
+func (p *ProviderInfo) ManifestFileNames() []string {
+	return []string{
+		p.TargetManifestFileName(),
+		"terraform-registry-manifest.json",
+	}
+}
+
 // InnerZipFileName returns the file name to be used inside the zip file,
 // without OS and ARCH information.
 func (p *ProviderInfo) InnerZipFileName() string {
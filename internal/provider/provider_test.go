@@ -60,6 +60,25 @@ func TestParseProviderFileName(t *testing.T) {
 			wantArch:    "386",
 			wantErr:     false,
 		},
+		{
+			name:        "prerelease version with build metadata",
+			filename:    "terraform-provider-aws_v2.0.0-beta.1+build.5_linux_amd64",
+			wantType:    "aws",
+			wantVersion: "2.0.0-beta.1+build.5",
+			wantOS:      "linux",
+			wantArch:    "amd64",
+			wantErr:     false,
+		},
+		{
+			name:     "non semantic version - should error",
+			filename: "terraform-provider-aws_v1.2_linux_amd64",
+			wantErr:  true,
+		},
+		{
+			name:     "version with leading zero - should error",
+			filename: "terraform-provider-aws_v1.02.0_linux_amd64",
+			wantErr:  true,
+		},
 		{
 			name:     "invalid filename format",
 			filename: "not-a-provider-file",
@@ -197,6 +216,41 @@ func TestProviderInfo_Paths(t *testing.T) {
 		}
 	})
 
+	t.Run("TargetH1Path", func(t *testing.T) {
+		expected := filepath.Join("example", "1.0.0", "download", "linux", "amd64", "terraform-provider-example_v1.0.0_linux_amd64.zip.h1")
+		if got := info.TargetH1Path(); got != expected {
+			t.Errorf("TargetH1Path() = %v, want %v", got, expected)
+		}
+	})
+
+	t.Run("TargetVersionSHASumsPath", func(t *testing.T) {
+		expected := filepath.Join("example", "1.0.0", "download", "terraform-provider-example_1.0.0_SHA256SUMS")
+		if got := info.TargetVersionSHASumsPath(); got != expected {
+			t.Errorf("TargetVersionSHASumsPath() = %v, want %v", got, expected)
+		}
+	})
+
+	t.Run("TargetVersionSigPath", func(t *testing.T) {
+		expected := filepath.Join("example", "1.0.0", "download", "terraform-provider-example_1.0.0_SHA256SUMS.sig")
+		if got := info.TargetVersionSigPath(); got != expected {
+			t.Errorf("TargetVersionSigPath() = %v, want %v", got, expected)
+		}
+	})
+
+	t.Run("TargetUpstreamZipFileName", func(t *testing.T) {
+		expected := "terraform-provider-example_1.0.0_linux_amd64.zip"
+		if got := info.TargetUpstreamZipFileName(); got != expected {
+			t.Errorf("TargetUpstreamZipFileName() = %v, want %v", got, expected)
+		}
+	})
+
+	t.Run("TargetManifestPath", func(t *testing.T) {
+		expected := filepath.Join("example", "1.0.0", "download", "terraform-provider-example_1.0.0_manifest.json")
+		if got := info.TargetManifestPath(); got != expected {
+			t.Errorf("TargetManifestPath() = %v, want %v", got, expected)
+		}
+	})
+
 	t.Run("IsZipFile", func(t *testing.T) {
 		tests := []struct {
 			filename string
@@ -234,3 +288,61 @@ func TestProviderInfo_Paths(t *testing.T) {
 		}
 	})
 }
+
+func TestProviderInfo_NamespacedPaths(t *testing.T) {
+	info := ProviderInfo{
+		Namespace: "team",
+		Type:      "example",
+		Version:   "1.0.0",
+		OS:        "linux",
+		Arch:      "amd64",
+	}
+
+	if got, expected := info.FullName(), "team/example"; got != expected {
+		t.Errorf("FullName() = %v, want %v", got, expected)
+	}
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"TargetBasePath", info.TargetBasePath(), filepath.Join("team", "example")},
+		{"TargetVersionPath", info.TargetVersionPath(), filepath.Join("team", "example", "1.0.0")},
+		{"TargetDownloadPath", info.TargetDownloadPath(), filepath.Join("team", "example", "1.0.0", "download", "linux", "amd64")},
+		{"TargetVersionsPath", info.TargetVersionsPath(), filepath.Join("team", "example", "versions")},
+		{"TargetVersionsIndexPath", info.TargetVersionsIndexPath(), filepath.Join("team", "example", "versions", "index.json")},
+		{"TargetZipPath", info.TargetZipPath(), filepath.Join("team", "example", "1.0.0", "download", "linux", "amd64", "terraform-provider-example_v1.0.0_linux_amd64.zip")},
+		{"TargetSigPath", info.TargetSigPath(), filepath.Join("team", "example", "1.0.0", "download", "linux", "amd64", "terraform-provider-example_v1.0.0_linux_amd64_SHA256SUMS.sig")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("%s() = %v, want %v", tt.name, tt.got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateNamespace(t *testing.T) {
+	tests := []struct {
+		namespace string
+		wantErr   bool
+	}{
+		{"hashicorp", false},
+		{"team-a", false},
+		{"Team_B2", false},
+		{"", true},
+		{"..", true},
+		{"team/a", true},
+		{"-team", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateNamespace(tt.namespace)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateNamespace(%q) error = %v, wantErr = %v", tt.namespace, err, tt.wantErr)
+		}
+	}
+}
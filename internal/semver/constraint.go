@@ -0,0 +1,120 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraints is a set of version constraints in the Terraform syntax, e.g., ">= 1.2.0, < 2.0.0" or "~> 1.2".
+// A version satisfies the set when it satisfies all the constraints.
+type Constraints []Constraint
+
+// Constraint is a single version constraint such as ">= 1.2.0".
+type Constraint struct {
+	op      string
+	version *Version
+	// upper is the exclusive upper bound of the "~>" operator.
+	upper *Version
+}
+
+// constraintOps are the operators of constraints. Longer ones are listed first to be matched first.
+var constraintOps = []string{"~>", ">=", "<=", "!=", ">", "<", "="}
+
+// ParseConstraints parses comma-separated version constraints.
+// Versions in constraints may omit the minor and patch versions, which default to 0.
+func ParseConstraints(s string) (Constraints, error) {
+	var cs Constraints
+	for _, part := range strings.Split(s, ",") {
+		c, err := parseConstraint(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+// parseConstraint parses a single version constraint.
+func parseConstraint(s string) (Constraint, error) {
+	c := Constraint{op: "="}
+	for _, op := range constraintOps {
+		if strings.HasPrefix(s, op) {
+			c.op = op
+			s = strings.TrimSpace(s[len(op):])
+			break
+		}
+	}
+	if s == "" {
+		return Constraint{}, fmt.Errorf("missing version")
+	}
+
+	// Complete the omitted minor and patch versions
+	core, suffix := s, ""
+	if idx := strings.IndexAny(s, "-+"); idx >= 0 {
+		core, suffix = s[:idx], s[idx:]
+	}
+	segments := len(strings.Split(core, "."))
+	if segments < 3 && suffix != "" {
+		return Constraint{}, fmt.Errorf("prerelease and build metadata require MAJOR.MINOR.PATCH: %q", s)
+	}
+	for i := segments; i < 3; i++ {
+		core += ".0"
+	}
+	v, err := Parse(core + suffix)
+	if err != nil {
+		return Constraint{}, err
+	}
+	c.version = v
+
+	if c.op == "~>" {
+		// Only the right-most specified version may increase
+		switch segments {
+		case 1, 2:
+			c.upper = &Version{Major: v.Major + 1}
+		default:
+			c.upper = &Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+	}
+	return c, nil
+}
+
+// Check returns whether the version satisfies all the constraints.
+func (cs Constraints) Check(v *Version) bool {
+	for _, c := range cs {
+		if !c.Check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns whether the version satisfies the constraint.
+func (c Constraint) Check(v *Version) bool {
+	cmp := Compare(v, c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		return cmp >= 0 && Compare(v, c.upper) < 0
+	}
+	return false
+}
+
+// String returns the constraints in the Terraform syntax.
+func (cs Constraints) String() string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = c.op + " " + c.version.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,51 @@
+package semver
+
+import "testing"
+
+func TestConstraints(t *testing.T) {
+	tests := []struct {
+		constraints string
+		version     string
+		want        bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"= 1.2.3", "1.2.4", false},
+		{"!= 1.2.3", "1.2.4", true},
+		{">= 1.2", "1.2.0", true},
+		{"> 1.2", "1.2.0", false},
+		{"< 2", "1.99.0", true},
+		{"<= 2", "2.0.1", false},
+		{">= 1.0.0, < 2.0.0", "1.5.0", true},
+		{">= 1.0.0, < 2.0.0", "2.0.0", false},
+		{"~> 1.2", "1.9.0", true},
+		{"~> 1.2", "2.0.0", false},
+		{"~> 1.2.3", "1.2.9", true},
+		{"~> 1.2.3", "1.3.0", false},
+		{"~> 1.2.3", "1.2.2", false},
+		{">= 2.0.0-beta.1", "2.0.0-beta.2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraints+" "+tt.version, func(t *testing.T) {
+			cs, err := ParseConstraints(tt.constraints)
+			if err != nil {
+				t.Fatalf("ParseConstraints(%q) error = %v", tt.constraints, err)
+			}
+			v, err := Parse(tt.version)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.version, err)
+			}
+			if got := cs.Check(v); got != tt.want {
+				t.Errorf("Check(%s) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraintsInvalid(t *testing.T) {
+	for _, s := range []string{"", ">=", "1.x", "~> 1.2-beta", ">= 1.0.0,"} {
+		if _, err := ParseConstraints(s); err == nil {
+			t.Errorf("ParseConstraints(%q) error = nil, want error", s)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package semver provides parsing and ordering of Semantic Versioning 2.0.0 version strings.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version represents a parsed semantic version.
+type Version struct {
+	Major      uint64   // Major version, e.g., 1 in "1.2.3"
+	Minor      uint64   // Minor version, e.g., 2 in "1.2.3"
+	Patch      uint64   // Patch version, e.g., 3 in "1.2.3"
+	Prerelease []string // Dot-separated prerelease identifiers, e.g., ["beta", "1"] in "1.2.3-beta.1"
+	Build      []string // Dot-separated build metadata identifiers, e.g., ["build", "5"] in "1.2.3+build.5"
+	original   string
+}
+
+// Parse parses a version string according to Semantic Versioning 2.0.0.
+// A leading "v" is not accepted; callers are expected to strip it beforehand.
+func Parse(s string) (*Version, error) {
+	if s == "" {
+		return nil, fmt.Errorf("invalid semantic version: empty string")
+	}
+
+	v := &Version{original: s}
+	rest := s
+
+	// Split off build metadata first, as it may contain hyphens
+	if idx := strings.IndexByte(rest, '+'); idx >= 0 {
+		build := rest[idx+1:]
+		rest = rest[:idx]
+		ids, err := parseIdentifiers(build, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic version %q: build metadata: %w", s, err)
+		}
+		v.Build = ids
+	}
+
+	// Split off prerelease
+	if idx := strings.IndexByte(rest, '-'); idx >= 0 {
+		pre := rest[idx+1:]
+		rest = rest[:idx]
+		ids, err := parseIdentifiers(pre, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic version %q: prerelease: %w", s, err)
+		}
+		v.Prerelease = ids
+	}
+
+	// Parse the version core
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	nums := make([]uint64, 3)
+	for i, part := range parts {
+		if !isNumeric(part) {
+			return nil, fmt.Errorf("invalid semantic version %q: %q is not a number", s, part)
+		}
+		if len(part) > 1 && part[0] == '0' {
+			return nil, fmt.Errorf("invalid semantic version %q: %q has a leading zero", s, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic version %q: %w", s, err)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	return v, nil
+}
+
+// parseIdentifiers parses dot-separated prerelease or build metadata identifiers.
+func parseIdentifiers(s string, prerelease bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if id == "" {
+			return nil, fmt.Errorf("empty identifier")
+		}
+		for _, c := range id {
+			if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && c != '-' {
+				return nil, fmt.Errorf("invalid character %q in identifier %q", c, id)
+			}
+		}
+		// Numeric prerelease identifiers must not include leading zeros
+		if prerelease && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("numeric identifier %q has a leading zero", id)
+		}
+	}
+	return ids, nil
+}
+
+// isNumeric returns whether s consists only of ASCII digits.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the original version string.
+func (v *Version) String() string {
+	return v.original
+}
+
+// IsPrerelease returns whether the version has prerelease identifiers.
+func (v *Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare compares two versions by precedence.
+// It returns -1 if a < b, 0 if a == b and +1 if a > b.
+// Build metadata is ignored as required by the specification.
+func Compare(a, b *Version) int {
+	if c := compareUint(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+
+	// A version without prerelease has higher precedence
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a.Prerelease)), uint64(len(b.Prerelease)))
+}
+
+// compareIdentifier compares a single pair of prerelease identifiers.
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		// Compare numerically without overflow by length first
+		if c := compareUint(uint64(len(a)), uint64(len(b))); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		// Numeric identifiers have lower precedence than alphanumeric ones
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareStrings compares two version strings.
+// Valid semantic versions are compared by precedence, falling back to a plain
+// string comparison when they have equal precedence (e.g., differing only in build metadata)
+// so that the ordering is total and deterministic.
+// Invalid versions are considered lower than any valid version and compared as plain strings.
+func CompareStrings(a, b string) int {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	switch {
+	case errA == nil && errB == nil:
+		if c := Compare(va, vb); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
@@ -0,0 +1,162 @@
+package semver
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        string
+		wantMajor      uint64
+		wantMinor      uint64
+		wantPatch      uint64
+		wantPrerelease int
+		wantBuild      int
+		wantErr        bool
+	}{
+		{
+			name:      "simple version",
+			version:   "1.2.3",
+			wantMajor: 1,
+			wantMinor: 2,
+			wantPatch: 3,
+		},
+		{
+			name:           "prerelease",
+			version:        "2.0.0-beta.1",
+			wantMajor:      2,
+			wantPrerelease: 2,
+		},
+		{
+			name:      "build metadata",
+			version:   "1.0.0+build.5",
+			wantMajor: 1,
+			wantBuild: 2,
+		},
+		{
+			name:           "prerelease and build metadata with hyphens",
+			version:        "1.0.0-rc-1+exp.sha-5114f85",
+			wantMajor:      1,
+			wantPrerelease: 1,
+			wantBuild:      2,
+		},
+		{
+			name:    "missing patch",
+			version: "1.0",
+			wantErr: true,
+		},
+		{
+			name:    "leading zero",
+			version: "01.0.0",
+			wantErr: true,
+		},
+		{
+			name:    "leading zero in numeric prerelease",
+			version: "1.0.0-01",
+			wantErr: true,
+		},
+		{
+			name:    "empty prerelease identifier",
+			version: "1.0.0-beta..1",
+			wantErr: true,
+		},
+		{
+			name:    "invalid character",
+			version: "1.0.0-beta_1",
+			wantErr: true,
+		},
+		{
+			name:    "v prefix",
+			version: "v1.0.0",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			version: "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.version)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) error = nil, wantErr = true", tt.version)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v, wantErr = false", tt.version, err)
+			}
+
+			if got.Major != tt.wantMajor || got.Minor != tt.wantMinor || got.Patch != tt.wantPatch {
+				t.Errorf("Parse(%q) = %d.%d.%d, want %d.%d.%d", tt.version, got.Major, got.Minor, got.Patch, tt.wantMajor, tt.wantMinor, tt.wantPatch)
+			}
+
+			if len(got.Prerelease) != tt.wantPrerelease {
+				t.Errorf("Prerelease = %v, want %d identifiers", got.Prerelease, tt.wantPrerelease)
+			}
+
+			if len(got.Build) != tt.wantBuild {
+				t.Errorf("Build = %v, want %d identifiers", got.Build, tt.wantBuild)
+			}
+
+			if got.String() != tt.version {
+				t.Errorf("String() = %q, want %q", got.String(), tt.version)
+			}
+		})
+	}
+}
+
+func TestCompareStrings(t *testing.T) {
+	// Example precedence from the SemVer 2.0.0 specification, plus multi-digit components
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.9.0",
+		"1.10.0",
+		"2.0.0-beta1",
+		"2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		if c := CompareStrings(ordered[i], ordered[i+1]); c != -1 {
+			t.Errorf("CompareStrings(%q, %q) = %d, want -1", ordered[i], ordered[i+1], c)
+		}
+		if c := CompareStrings(ordered[i+1], ordered[i]); c != 1 {
+			t.Errorf("CompareStrings(%q, %q) = %d, want 1", ordered[i+1], ordered[i], c)
+		}
+	}
+
+	t.Run("build metadata", func(t *testing.T) {
+		a, _ := Parse("1.0.0+build.1")
+		b, _ := Parse("1.0.0+build.2")
+		if c := Compare(a, b); c != 0 {
+			t.Errorf("Compare() = %d, want 0 (build metadata must be ignored)", c)
+		}
+		if c := CompareStrings("1.0.0+build.1", "1.0.0+build.2"); c != -1 {
+			t.Errorf("CompareStrings() = %d, want -1 (deterministic tie-break)", c)
+		}
+	})
+
+	t.Run("invalid versions sort lowest", func(t *testing.T) {
+		versions := []string{"not-a-version", "0.0.1", "1.0"}
+		sort.Slice(versions, func(i, j int) bool {
+			return CompareStrings(versions[i], versions[j]) < 0
+		})
+		if versions[2] != "0.0.1" {
+			t.Errorf("Sorted versions = %v, want valid version last", versions)
+		}
+	})
+}
@@ -2,35 +2,775 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ikedam/terraform-registry-builder/builder"
+	"github.com/ikedam/terraform-registry-builder/file"
+	"github.com/ikedam/terraform-registry-builder/internal/semver"
 )
 
+// protocolsFlag collects per-provider protocol overrides in the form TYPE=PROTOCOL[,PROTOCOL...].
+type protocolsFlag map[string][]string
+
+func (f protocolsFlag) String() string {
+	var parts []string
+	for providerType, protocols := range f {
+		parts = append(parts, providerType+"="+strings.Join(protocols, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f protocolsFlag) Set(value string) error {
+	providerType, protocols, ok := strings.Cut(value, "=")
+	if !ok || providerType == "" || protocols == "" {
+		return fmt.Errorf("expected TYPE=PROTOCOL[,PROTOCOL...]: %s", value)
+	}
+	f[providerType] = strings.Split(protocols, ",")
+	return nil
+}
+
+// layoutFlags are the options describing the layout of DST, shared by the commands.
+type layoutFlags struct {
+	versionSHASums      *bool
+	siteRoot            *bool
+	namespace           *string
+	namespaceFromDir    *bool
+	namespaceConfigPath *string
+	providersPath       *string
+	modulesPath         *string
+	downloadURL         *string
+}
+
+// registerLayoutFlags registers the options describing the layout of DST to the flag set.
+func registerLayoutFlags(fs *flag.FlagSet) *layoutFlags {
+	return &layoutFlags{
+		versionSHASums:      fs.Bool("shasums-per-version", false, "Maintain a single SHA256SUMS file per version covering all platforms (goreleaser compatible)"),
+		siteRoot:            fs.Bool("site-root", false, "Treat DST as the site root (implied by the namespace options, required for modules)"),
+		namespace:           fs.String("namespace", "", "Treat DST as the site root and publish providers under this namespace"),
+		namespaceFromDir:    fs.Bool("namespace-from-dir", false, "Treat DST as the site root and use the first level sub-directory of SRC as the namespace"),
+		namespaceConfigPath: fs.String("namespace-config", "", "Treat DST as the site root and map files to namespaces with this JSON config file"),
+		providersPath:       fs.String("providers-path", builder.DefaultProvidersPath, "Path of the provider registry declared in .well-known/terraform.json"),
+		modulesPath:         fs.String("modules-path", builder.DefaultModulesPath, "Path of the module registry declared in .well-known/terraform.json"),
+		downloadURL:         fs.String("download-url", "", "Base URL or URL template ({namespace}, {type}, {version}, {os}, {arch}, {filename}, {path}) of the files referenced by download indexes"),
+	}
+}
+
+// options returns the builder options for the flags.
+func (f *layoutFlags) options() ([]builder.Option, error) {
+	opts := []builder.Option{
+		builder.WithVersionSHASums(*f.versionSHASums),
+		builder.WithSiteRoot(*f.siteRoot),
+		builder.WithNamespace(*f.namespace),
+		builder.WithNamespaceFromDir(*f.namespaceFromDir),
+		builder.WithProvidersPath(*f.providersPath),
+		builder.WithModulesPath(*f.modulesPath),
+	}
+	if *f.namespaceConfigPath != "" {
+		namespaceConfig, err := builder.LoadNamespaceConfig(*f.namespaceConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, builder.WithNamespaceConfig(namespaceConfig))
+	}
+	if *f.downloadURL != "" {
+		downloadURL, err := builder.ParseDownloadURLTemplate(*f.downloadURL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, builder.WithDownloadURL(downloadURL))
+	}
+	return opts, nil
+}
+
+// lockFlags are the options of the lock of DST, shared by the commands writing to DST.
+type lockFlags struct {
+	timeout  *time.Duration
+	staleAge *time.Duration
+}
+
+// registerLockFlags registers the options of the lock of DST to the flag set.
+func registerLockFlags(fs *flag.FlagSet) *lockFlags {
+	return &lockFlags{
+		timeout:  fs.Duration("lock-timeout", builder.DefaultLockTimeout, "How long to wait for the lock of DST held by another process"),
+		staleAge: fs.Duration("lock-stale-age", builder.DefaultStaleLockAge, "Age after which a lock of DST not refreshed by its holder is taken over"),
+	}
+}
+
+// options returns the builder options for the flags.
+func (f *lockFlags) options() []builder.Option {
+	return []builder.Option{
+		builder.WithLockTimeout(*f.timeout),
+		builder.WithStaleLockAge(*f.staleAge),
+	}
+}
+
+// mirrorFlags are the options of the mirrors, shared by the commands publishing or removing providers.
+type mirrorFlags struct {
+	networkMirror            *string
+	filesystemMirror         *string
+	filesystemMirrorHostname *string
+	filesystemMirrorUnpacked *bool
+}
+
+// registerMirrorFlags registers the options of the mirrors to the flag set.
+func registerMirrorFlags(fs *flag.FlagSet) *mirrorFlags {
+	return &mirrorFlags{
+		networkMirror:            fs.String("network-mirror", "", "Also write the provider network mirror tree for this hostname under DST/<hostname>"),
+		filesystemMirror:         fs.String("filesystem-mirror", "", "Also write providers to this filesystem mirror directory"),
+		filesystemMirrorHostname: fs.String("filesystem-mirror-hostname", "", "Hostname of the providers in the filesystem mirror"),
+		filesystemMirrorUnpacked: fs.Bool("filesystem-mirror-unpacked", false, "Use the unpacked layout for the filesystem mirror instead of the packed layout"),
+	}
+}
+
+// options returns the builder options for the flags.
+func (f *mirrorFlags) options() []builder.Option {
+	opts := []builder.Option{builder.WithNetworkMirror(*f.networkMirror)}
+	if *f.filesystemMirror != "" {
+		opts = append(opts, builder.WithFilesystemMirror(&builder.FilesystemMirror{
+			Dir:      *f.filesystemMirror,
+			Hostname: *f.filesystemMirrorHostname,
+			Unpacked: *f.filesystemMirrorUnpacked,
+		}))
+	}
+	return opts
+}
+
+// signingTokenEnv is the environment variable of the bearer token of the signing service.
+const signingTokenEnv = "TFREGBUILDER_SIGNING_TOKEN"
+
+// signerFlags are the options of the signer, shared by the commands signing SHA256SUMS files.
+type signerFlags struct {
+	command          *string
+	publicKeyCommand *string
+	url              *string
+	publicKeyURL     *string
+	keyID            *string
+}
+
+// registerSignerFlags registers the options of the signer to the flag set.
+func registerSignerFlags(fs *flag.FlagSet) *signerFlags {
+	return &signerFlags{
+		command:          fs.String("sign-command", "", "Sign with this command reading data from stdin and writing the detached signature to stdout, e.g., \"gpg --batch --detach-sign --local-user KEY\" (arguments are separated by spaces)"),
+		publicKeyCommand: fs.String("sign-public-key-command", "", "Command writing the armored public key of -sign-command to stdout, e.g., \"gpg --armor --export KEY\""),
+		url:              fs.String("sign-url", "", "Sign with the signing service receiving data posted to this URL and returning the detached signature (bearer token in "+signingTokenEnv+")"),
+		publicKeyURL:     fs.String("sign-public-key-url", "", "URL of the signing service returning the armored public key of -sign-url"),
+		keyID:            fs.String("sign-key-id", "", "Key ID of -sign-command or -sign-url listed in download indexes (defaults to the one of the public key)"),
+	}
+}
+
+// options returns the builder options for the flags.
+// Without -sign-command or -sign-url, files are signed with the key in TFREGBUILDER_GPG_* environment variables.
+func (f *signerFlags) options() ([]builder.Option, error) {
+	switch {
+	case *f.command != "" && *f.url != "":
+		return nil, fmt.Errorf("-sign-command and -sign-url cannot be specified together")
+	case *f.command != "":
+		if *f.publicKeyURL != "" {
+			return nil, fmt.Errorf("-sign-public-key-url requires -sign-url")
+		}
+		signer, err := file.NewCommandSigner(strings.Fields(*f.command), strings.Fields(*f.publicKeyCommand), *f.keyID)
+		if err != nil {
+			return nil, err
+		}
+		return []builder.Option{builder.WithSigner(signer)}, nil
+	case *f.url != "":
+		if *f.publicKeyCommand != "" {
+			return nil, fmt.Errorf("-sign-public-key-command requires -sign-command")
+		}
+		if *f.publicKeyURL == "" {
+			return nil, fmt.Errorf("-sign-public-key-url is required with -sign-url")
+		}
+		signer, err := file.NewRemoteSigner(*f.url, *f.publicKeyURL, os.Getenv(signingTokenEnv), *f.keyID)
+		if err != nil {
+			return nil, err
+		}
+		return []builder.Option{builder.WithSigner(signer)}, nil
+	case *f.publicKeyCommand != "" || *f.publicKeyURL != "" || *f.keyID != "":
+		return nil, fmt.Errorf("-sign-public-key-command, -sign-public-key-url and -sign-key-id require -sign-command or -sign-url")
+	}
+	return nil, nil
+}
+
+// constraintsFlag collects version constraints, one set per occurrence.
+type constraintsFlag []semver.Constraints
+
+func (f *constraintsFlag) String() string {
+	var parts []string
+	for _, constraints := range *f {
+		parts = append(parts, constraints.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *constraintsFlag) Set(value string) error {
+	constraints, err := semver.ParseConstraints(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, constraints)
+	return nil
+}
+
+// pruneFlags are the rules of pruning provider versions, shared by the build and the prune commands.
+type pruneFlags struct {
+	keepStable         *int
+	keepPrereleaseDays *int
+	keep               constraintsFlag
+}
+
+// registerPruneFlags registers the rules of pruning provider versions to the flag set.
+func registerPruneFlags(fs *flag.FlagSet) *pruneFlags {
+	f := &pruneFlags{
+		keepStable:         fs.Int("keep-stable", 0, "Prune stable versions except this number of the newest ones (0 keeps all)"),
+		keepPrereleaseDays: fs.Int("keep-prerelease-days", 0, "Prune prereleases published more than this number of days ago (0 keeps all)"),
+	}
+	fs.Var(&f.keep, "keep-version", "Never prune versions matching the constraints, e.g. \"~> 1.2\" (repeatable)")
+	return f
+}
+
+// rules returns the prune rules for the flags.
+func (f *pruneFlags) rules() *builder.PruneRules {
+	return &builder.PruneRules{
+		KeepStable:         *f.keepStable,
+		KeepPrereleasesFor: time.Duration(*f.keepPrereleaseDays) * 24 * time.Hour,
+		Keep:               f.keep,
+	}
+}
+
+// errChangesPending reports that a dry run found changes to publish.
+var errChangesPending = errors.New("changes pending")
+
+// exitChangesPending is the exit code of a dry run finding changes to publish.
+const exitChangesPending = 2
+
+// commands are the sub-commands. Without a sub-command, SRC is built into DST.
+var commands = map[string]func(args []string) error{
+	"rewrite-urls": runRewriteURLs,
+	"lock":         runLock,
+	"remove":       runRemove,
+	"prune":        runPrune,
+	"warnings":     runWarnings,
+	"verify":       runVerify,
+	"reindex":      runReindex,
+	"resign":       runResign,
+}
+
 func main() {
-	// Parse command line arguments
-	flag.Parse()
-	args := flag.Args()
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		}
+	}
 
-	if len(args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s SRC DST\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  SRC: Directory containing provider binaries or packages\n")
-		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace\n")
+	if err := runBuild(os.Args[1:]); err != nil {
+		exit(err)
+	}
+}
+
+// exit terminates the process with the exit code for the error.
+func exit(err error) {
+	if errors.Is(err, errChangesPending) {
+		os.Exit(exitChangesPending)
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+// runBuild builds the registry structure in DST from SRC.
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	protocols := protocolsFlag{}
+	fs.Var(protocols, "protocols", "Override protocol versions of a provider as TYPE=PROTOCOL[,PROTOCOL...] (repeatable)")
+	force := fs.Bool("force", false, "Republish versions/platforms whose content differs from the published one")
+	workers := fs.Int("workers", 1, "Number of provider files and modules processed concurrently (1 processes them in order)")
+	goreleaser := fs.Bool("goreleaser", false, "Treat SRC as a goreleaser dist directory and publish the archives listed in artifacts.json")
+	networkMirrorOnly := fs.Bool("network-mirror-only", false, "Write only the provider network mirror tree instead of the registry tree")
+	dryRun := fs.Bool("dry-run", false, "Print the plan of the build without writing to DST, exiting with 2 when there are changes to publish")
+	planJSON := fs.Bool("plan-json", false, "Print the plan of -dry-run as JSON")
+	reindex := fs.Bool("reindex", false, "Rebuild corrupted or lost versions indexes from the download directories")
+	layout := registerLayoutFlags(fs)
+	lock := registerLockFlags(fs)
+	signing := registerSignerFlags(fs)
+	mirror := registerMirrorFlags(fs)
+	prune := registerPruneFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] SRC DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s rewrite-urls [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s lock [OPTIONS] DST HOSTNAME/NAMESPACE/TYPE VERSION\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s remove [OPTIONS] DST [NAMESPACE/]TYPE VERSION [OS/ARCH]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s prune [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s warnings [OPTIONS] DST [NAMESPACE/]TYPE [MESSAGE...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s verify [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s reindex [OPTIONS] DST [[NAMESPACE/]TYPE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s resign [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  SRC: Directory containing provider binaries or packages, and module sources\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	// Parse command line arguments
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
 		os.Exit(1)
 	}
 
-	srcDir := args[0]
-	dstDir := args[1]
+	srcDir := fs.Arg(0)
+	dstDir := fs.Arg(1)
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts,
+		builder.WithProtocols(protocols),
+		builder.WithForce(*force),
+		builder.WithWorkers(*workers),
+		builder.WithGoreleaser(*goreleaser),
+		builder.WithNetworkMirrorOnly(*networkMirrorOnly),
+		builder.WithDryRun(*dryRun),
+		builder.WithPrune(prune.rules()),
+		builder.WithReindex(*reindex),
+	)
+	opts = append(opts, lock.options()...)
+	signerOpts, err := signing.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, signerOpts...)
+	opts = append(opts, mirror.options()...)
 
 	// Create and run the builder
-	b := builder.New(srcDir, dstDir)
-	err := b.Build()
+	b := builder.New(srcDir, dstDir, opts...)
+	if err := b.Build(); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		return printPlan(b.Plan(), *planJSON)
+	}
+
+	fmt.Println("Build completed successfully.")
+	return nil
+}
+
+// printPlan prints the plan of a dry run and reports conflicts and pending changes as errors.
+func printPlan(plan *builder.Plan, asJSON bool) error {
+	if asJSON {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal plan: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		plan.WriteText(os.Stdout)
+	}
+
+	if plan.HasConflicts() {
+		return fmt.Errorf("%d versions/platforms are already published with different content (use force mode to republish)", plan.Count(builder.PlanConflict))
+	}
+	if plan.HasChanges() {
+		return errChangesPending
+	}
+	return nil
+}
+
+// runRewriteURLs rewrites the URLs in the download indexes published in DST.
+func runRewriteURLs(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" rewrite-urls", flag.ExitOnError)
+	layout := registerLayoutFlags(fs)
+	lock := registerLockFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s rewrite-urls [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Rewrites the URLs in the published download indexes following -download-url.\n")
+		fmt.Fprintf(os.Stderr, "  Relative URLs are written when -download-url is not specified.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts, err := layout.options()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return err
+	}
+	opts = append(opts, lock.options()...)
+
+	b := builder.New("", fs.Arg(0), opts...)
+	if err := b.RewriteURLs(); err != nil {
+		return err
+	}
+
+	fmt.Println("Rewrite completed successfully.")
+	return nil
+}
+
+// runLock prints a dependency lock file entry for a provider version published in DST.
+func runLock(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" lock", flag.ExitOnError)
+	constraints := fs.String("constraints", "", "Version constraints to record in the provider block")
+	layout := registerLayoutFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s lock [OPTIONS] DST HOSTNAME/NAMESPACE/TYPE VERSION\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Prints the .terraform.lock.hcl provider block covering all platforms of the version.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 3 {
+		fs.Usage()
 		os.Exit(1)
 	}
 
-	fmt.Println("Build completed successfully.")
+	address := fs.Arg(1)
+	version := fs.Arg(2)
+	parts := strings.Split(address, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return fmt.Errorf("provider address must be HOSTNAME/NAMESPACE/TYPE: %s", address)
+	}
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+
+	b := builder.New("", fs.Arg(0), opts...)
+	hashes, err := b.LockHashes(parts[1], parts[2], version)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(file.FormatLockProvider(address, version, *constraints, hashes))
+	return nil
+}
+
+// runRemove removes a provider version, or a platform of it, published in DST.
+func runRemove(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" remove", flag.ExitOnError)
+	layout := registerLayoutFlags(fs)
+	lock := registerLockFlags(fs)
+	signing := registerSignerFlags(fs)
+	mirror := registerMirrorFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s remove [OPTIONS] DST [NAMESPACE/]TYPE VERSION [OS/ARCH]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Removes all platforms of the provider version, or only the platform OS/ARCH.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "  NAMESPACE defaults to -namespace when DST is the site root.\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 3 && fs.NArg() != 4 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	namespace, providerType, err := parseProvider(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	var osName, arch string
+	if fs.NArg() == 4 {
+		var ok bool
+		osName, arch, ok = strings.Cut(fs.Arg(3), "/")
+		if !ok || osName == "" || arch == "" {
+			return fmt.Errorf("platform must be OS/ARCH: %s", fs.Arg(3))
+		}
+	}
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, lock.options()...)
+	signerOpts, err := signing.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, signerOpts...)
+	opts = append(opts, mirror.options()...)
+
+	b := builder.New("", fs.Arg(0), opts...)
+	if err := b.Remove(namespace, providerType, fs.Arg(2), osName, arch); err != nil {
+		return err
+	}
+
+	fmt.Println("Remove completed successfully.")
+	return nil
+}
+
+// parseProvider parses a provider argument in the form [NAMESPACE/]TYPE.
+func parseProvider(arg string) (string, string, error) {
+	var namespace, providerType string
+	if ns, t, ok := strings.Cut(arg, "/"); ok {
+		namespace, providerType = ns, t
+	} else {
+		providerType = arg
+	}
+	if providerType == "" || strings.Contains(providerType, "/") {
+		return "", "", fmt.Errorf("provider must be [NAMESPACE/]TYPE: %s", arg)
+	}
+	return namespace, providerType, nil
+}
+
+// runPrune removes the provider versions published in DST selected by the prune rules.
+func runPrune(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" prune", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Print the versions to prune without removing them, exiting with 2 when there are versions to prune")
+	planJSON := fs.Bool("plan-json", false, "Print the plan of -dry-run as JSON")
+	layout := registerLayoutFlags(fs)
+	lock := registerLockFlags(fs)
+	signing := registerSignerFlags(fs)
+	mirror := registerMirrorFlags(fs)
+	prune := registerPruneFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s prune [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Removes the versions of all published providers not kept by the rules.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, lock.options()...)
+	signerOpts, err := signing.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, signerOpts...)
+	opts = append(opts, mirror.options()...)
+	opts = append(opts,
+		builder.WithDryRun(*dryRun),
+		builder.WithPrune(prune.rules()),
+	)
+
+	b := builder.New("", fs.Arg(0), opts...)
+	if err := b.Prune(); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		return printPlan(b.Plan(), *planJSON)
+	}
+
+	fmt.Println("Prune completed successfully.")
+	return nil
+}
+
+// runWarnings shows, sets or clears the provider-level warnings of a provider published in DST.
+func runWarnings(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" warnings", flag.ExitOnError)
+	clearWarnings := fs.Bool("clear", false, "Clear the warnings of the provider")
+	layout := registerLayoutFlags(fs)
+	lock := registerLockFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s warnings [OPTIONS] DST [NAMESPACE/]TYPE [MESSAGE...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Replaces the warnings Terraform shows for the provider with the messages.\n")
+		fmt.Fprintf(os.Stderr, "  Prints the current warnings when no messages are given and -clear is not specified.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "  NAMESPACE defaults to -namespace when DST is the site root.\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() < 2 || (*clearWarnings && fs.NArg() > 2) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	namespace, providerType, err := parseProvider(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, lock.options()...)
+
+	b := builder.New("", fs.Arg(0), opts...)
+	messages := fs.Args()[2:]
+	if len(messages) == 0 && !*clearWarnings {
+		warnings, err := b.Warnings(namespace, providerType)
+		if err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			fmt.Println(warning)
+		}
+		return nil
+	}
+
+	for _, message := range messages {
+		if strings.TrimSpace(message) == "" {
+			return fmt.Errorf("warning message must not be empty")
+		}
+	}
+	if err := b.SetWarnings(namespace, providerType, messages); err != nil {
+		return err
+	}
+
+	fmt.Println("Warnings updated successfully.")
+	return nil
+}
+
+// runVerify checks the integrity of all providers published in DST.
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" verify", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	layout := registerLayoutFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s verify [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Checks the files, hashes and signatures of all published providers, exiting with 1 on any problem.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+
+	b := builder.New("", fs.Arg(0), opts...)
+	report, err := b.Verify()
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		report.WriteText(os.Stdout)
+	}
+
+	if n := report.ProblemCount(); n > 0 {
+		return fmt.Errorf("verification found %d problems", n)
+	}
+	return nil
+}
+
+// runReindex rebuilds the versions indexes published in DST from the download directories.
+func runReindex(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" reindex", flag.ExitOnError)
+	layout := registerLayoutFlags(fs)
+	lock := registerLockFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s reindex [OPTIONS] DST [[NAMESPACE/]TYPE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Rebuilds the versions index of the provider, or of all providers, from the download directories.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "  NAMESPACE defaults to -namespace when DST is the site root.\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 && fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var namespace, providerType string
+	if fs.NArg() == 2 {
+		var err error
+		if namespace, providerType, err = parseProvider(fs.Arg(1)); err != nil {
+			return err
+		}
+	}
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, lock.options()...)
+
+	b := builder.New("", fs.Arg(0), opts...)
+	if err := b.Reindex(namespace, providerType); err != nil {
+		return err
+	}
+
+	fmt.Println("Reindex completed successfully.")
+	return nil
+}
+
+// runResign signs the providers published in DST again with the current signing key.
+func runResign(args []string) error {
+	fs := flag.NewFlagSet(os.Args[0]+" resign", flag.ExitOnError)
+	keepOldKeys := fs.Bool("keep-old-keys", false, "Keep the signing keys already listed in the download indexes after the current one")
+	layout := registerLayoutFlags(fs)
+	lock := registerLockFlags(fs)
+	signing := registerSignerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s resign [OPTIONS] DST\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Signs the SHA256SUMS files of all published providers with the key in TFREGBUILDER_GPG_*,\n")
+		fmt.Fprintf(os.Stderr, "  and replaces the signing keys in the download indexes.\n")
+		fmt.Fprintf(os.Stderr, "  DST: Directory for the Terraform registry namespace, or the site root\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts, err := layout.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, lock.options()...)
+	signerOpts, err := signing.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, signerOpts...)
+
+	b := builder.New("", fs.Arg(0), opts...)
+	if err := b.Resign(*keepOldKeys); err != nil {
+		return err
+	}
+
+	fmt.Println("Resign completed successfully.")
+	return nil
 }